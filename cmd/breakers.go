@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// breakerStateFile holds the circuit breaker state between runs, inside the user cache directory.
+const breakerStateFile = "breakers.json"
+
+// breakerStatePath returns where circuit breaker state is saved, or "" if there is no cache directory.
+func breakerStatePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "ip-enrich", breakerStateFile)
+}
+
+// loadBreakerStates reads the saved circuit breaker state. A missing file yields no state.
+func loadBreakerStates(path string) (map[string]provider.BreakerState, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var states map[string]provider.BreakerState
+	if err := json.Unmarshal(data, &states); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return states, nil
+}
+
+// saveBreakerStates atomically writes the circuit breaker state, so a concurrent
+// run never reads a half-written file.
+func saveBreakerStates(path string, states map[string]provider.BreakerState) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, breakerStateFile+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
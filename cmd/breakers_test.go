@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+func TestBreakerStatesPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ip-enrich", breakerStateFile)
+
+	states, err := loadBreakerStates(path)
+	if err != nil || states != nil {
+		t.Fatalf("loadBreakerStates() with no file = %v, %v; want nil, nil", states, err)
+	}
+
+	openedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	want := map[string]provider.BreakerState{
+		"shodan": {State: provider.CircuitOpen, Failures: 5, OpenedAt: openedAt},
+	}
+	if err := saveBreakerStates(path, want); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := loadBreakerStates(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["shodan"] != want["shodan"] {
+		t.Errorf("loaded %+v, want %+v", got["shodan"], want["shodan"])
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadBreakerStates(path); err == nil {
+		t.Error("loadBreakerStates() accepted a corrupt file")
+	}
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"github.com/dalryan/ip-enrich/internal/config"
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// loadConfig reads the config file and applies provider settings.
+func loadConfig() (*config.Config, error) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	dataDir, err := cfg.ResolveDataDir()
+	if err != nil {
+		return nil, err
+	}
+	provider.SetDataDir(dataDir)
+
+	if err := provider.Configure(cfg.Providers); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dalryan/ip-enrich/internal/output"
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// Process exit codes.
+const (
+	// ExitOK means the run completed and no fail-on condition was met.
+	ExitOK = 0
+	// ExitFailure is used for unexpected errors (bad flags, output failures).
+	ExitFailure = 1
+	// ExitInvalidInput means the target or arguments were invalid.
+	ExitInvalidInput = 2
+	// ExitAllFailed means every selected provider (or feed, for "feeds update") failed.
+	ExitAllFailed = 3
+	// ExitPartialFailure means a partial or any-error fail-on condition was met,
+	// or some feeds failed to update.
+	ExitPartialFailure = 4
+	// ExitMalicious means the overall verdict was malicious and --fail-on malicious was set.
+	ExitMalicious = 5
+)
+
+// Fail-on conditions accepted by --fail-on.
+const (
+	failOnPartial   = "partial"
+	failOnAnyError  = "any-error"
+	failOnMalicious = "malicious"
+)
+
+// ExitError carries the process exit code for an error returned from Execute.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// invalidInput wraps err with ExitInvalidInput.
+func invalidInput(err error) error {
+	return &ExitError{Code: ExitInvalidInput, Err: err}
+}
+
+// validateFailOn checks that every --fail-on value is known.
+func validateFailOn(conditions []string) error {
+	for _, c := range conditions {
+		switch c {
+		case failOnPartial, failOnAnyError, failOnMalicious:
+		default:
+			return invalidInput(fmt.Errorf("unknown --fail-on condition: %s (supported: %s, %s, %s)",
+				c, failOnPartial, failOnAnyError, failOnMalicious))
+		}
+	}
+	return nil
+}
+
+// outcome maps a finished report to an exit error according to the fail-on conditions.
+// It returns nil if the run should exit with ExitOK.
+func outcome(report *output.Report, conditions []string) error {
+	enabled := make(map[string]bool, len(conditions))
+	for _, c := range conditions {
+		enabled[c] = true
+	}
+
+	if enabled[failOnMalicious] && report.Verdict == provider.VerdictMalicious {
+		return &ExitError{Code: ExitMalicious, Err: fmt.Errorf("verdict is %s", report.Verdict)}
+	}
+
+	failed, total := report.Failed(), report.Requested()
+	if total > 0 && failed == total {
+		return &ExitError{Code: ExitAllFailed, Err: fmt.Errorf("all %d providers failed", total)}
+	}
+
+	// partial: the run produced some data, but not all of it.
+	if enabled[failOnPartial] && failed > 0 && failed < total {
+		return &ExitError{Code: ExitPartialFailure, Err: fmt.Errorf("%d of %d providers failed", failed, total)}
+	}
+
+	// any-error: anything went wrong, including problems inside successful results.
+	degraded := report.Degraded()
+	if enabled[failOnAnyError] && (failed > 0 || degraded > 0) {
+		err := fmt.Errorf("%d of %d providers failed, %d reported warnings", failed, total, degraded)
+		return &ExitError{Code: ExitPartialFailure, Err: err}
+	}
+
+	return nil
+}
+
+// ExitCode returns the process exit code for an error returned from Execute.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return ExitFailure
+}
+
+// failOnUsage is the help text for --fail-on.
+var failOnUsage = "Exit non-zero when a condition is met: " + strings.Join([]string{failOnPartial, failOnAnyError, failOnMalicious}, ", ")
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dalryan/ip-enrich/internal/output"
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// okResult, failedResult and warnedResult build results for outcome tests.
+func okResult(verdict provider.Verdict) *provider.Result {
+	return &provider.Result{ProviderID: "ok", Verdict: verdict}
+}
+
+func failedResult() *provider.Result {
+	return &provider.Result{ProviderID: "failed", Error: "boom", ErrorKind: provider.ErrorKindNetwork}
+}
+
+func warnedResult() *provider.Result {
+	return &provider.Result{ProviderID: "warned", Warnings: []string{"reputation: timeout"}}
+}
+
+// implicit marks r as coming from a provider that only ran as a dependency.
+func implicit(r *provider.Result) *provider.Result {
+	r.Implicit = true
+	return r
+}
+
+func TestOutcome(t *testing.T) {
+	tests := []struct {
+		name       string
+		results    []*provider.Result
+		conditions []string
+		want       int
+	}{
+		{name: "all ok", results: []*provider.Result{okResult(""), okResult("")}, want: ExitOK},
+		{name: "partial failure without conditions", results: []*provider.Result{okResult(""), failedResult()}, want: ExitOK},
+		{name: "all failed", results: []*provider.Result{failedResult(), failedResult()}, want: ExitAllFailed},
+		{name: "all failed with partial", results: []*provider.Result{failedResult()}, conditions: []string{failOnPartial}, want: ExitAllFailed},
+		{name: "partial", results: []*provider.Result{okResult(""), failedResult()}, conditions: []string{failOnPartial}, want: ExitPartialFailure},
+		{name: "partial ignores warnings", results: []*provider.Result{okResult(""), warnedResult()}, conditions: []string{failOnPartial}, want: ExitOK},
+		{name: "any-error on failure", results: []*provider.Result{okResult(""), failedResult()}, conditions: []string{failOnAnyError}, want: ExitPartialFailure},
+		{name: "any-error on warnings", results: []*provider.Result{okResult(""), warnedResult()}, conditions: []string{failOnAnyError}, want: ExitPartialFailure},
+		{name: "any-error clean run", results: []*provider.Result{okResult(""), okResult("")}, conditions: []string{failOnAnyError}, want: ExitOK},
+		{name: "malicious", results: []*provider.Result{okResult(provider.VerdictMalicious)}, conditions: []string{failOnMalicious}, want: ExitMalicious},
+		{name: "malicious without condition", results: []*provider.Result{okResult(provider.VerdictMalicious)}, want: ExitOK},
+		{name: "malicious wins over partial", results: []*provider.Result{okResult(provider.VerdictMalicious), failedResult()}, conditions: []string{failOnPartial, failOnMalicious}, want: ExitMalicious},
+		{name: "suspicious is not malicious", results: []*provider.Result{okResult(provider.VerdictSuspicious)}, conditions: []string{failOnMalicious}, want: ExitOK},
+		{name: "implicit failure is not partial", results: []*provider.Result{okResult(""), implicit(failedResult())}, conditions: []string{failOnPartial, failOnAnyError}, want: ExitOK},
+		{name: "implicit warnings are not any-error", results: []*provider.Result{okResult(""), implicit(warnedResult())}, conditions: []string{failOnAnyError}, want: ExitOK},
+		{name: "requested all failed despite implicit success", results: []*provider.Result{failedResult(), implicit(okResult(""))}, want: ExitAllFailed},
+		{name: "implicit verdict is ignored", results: []*provider.Result{okResult(""), implicit(okResult(provider.VerdictMalicious))}, conditions: []string{failOnMalicious}, want: ExitOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := output.NewReport("192.0.2.1", "", tt.results)
+			if got := ExitCode(outcome(report, tt.conditions)); got != tt.want {
+				t.Errorf("exit code = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "success", err: nil, want: ExitOK},
+		{name: "unexpected error", err: errors.New("write failed"), want: ExitFailure},
+		{name: "invalid input", err: invalidInput(errors.New("bad ip")), want: ExitInvalidInput},
+		{name: "unknown fail-on condition", err: validateFailOn([]string{"sometimes"}), want: ExitInvalidInput},
+		{name: "wrapped exit error", err: &ExitError{Code: ExitAllFailed, Err: errors.New("all failed")}, want: ExitAllFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
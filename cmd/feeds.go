@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dalryan/ip-enrich/internal/feeds"
+	"github.com/dalryan/ip-enrich/internal/provider"
+	"github.com/spf13/cobra"
+)
+
+var forceUpdate bool
+
+// feedsCmd groups the commands that manage downloaded feeds and databases.
+var feedsCmd = &cobra.Command{
+	Use:   "feeds",
+	Short: "Manage downloaded blocklists and databases",
+	Long: `Download the feeds listed in the config file into the local data directory.
+
+Local-data providers (mmdb, threatfeed, tor, cloud) resolve relative paths
+against the same directory, so a feed's file name can be used directly in
+their settings. The Tor exit list is a built-in feed and needs no configuration.`,
+}
+
+// feedsListCmd shows the configured feeds.
+var feedsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List configured feeds",
+	Args:  cobra.NoArgs,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return invalidInput(err)
+		}
+
+		selected, err := feeds.Select(cfg.Feeds, nil)
+		if err != nil {
+			return invalidInput(err)
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+		if _, err := fmt.Fprintln(w, "NAME\tFILE\tURL"); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w, "----\t----\t---"); err != nil {
+			return err
+		}
+		for _, f := range selected {
+			if _, err := fmt.Fprintf(w, "%s\t%s\t%s\n", f.Name, f.FileName(), f.URL); err != nil {
+				return err
+			}
+		}
+		return w.Flush()
+	},
+}
+
+// feedsStatusCmd shows the local state of every configured feed.
+var feedsStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show when each feed was last updated",
+	Args:  cobra.NoArgs,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return invalidInput(err)
+		}
+
+		selected, err := feeds.Select(cfg.Feeds, nil)
+		if err != nil {
+			return invalidInput(err)
+		}
+
+		dir := provider.DataDir()
+		states, err := feeds.LoadState(dir)
+		if err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintf(cmd.OutOrStdout(), "Data directory: %s\n\n", dir); err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+		if _, err := fmt.Fprintln(w, "NAME\tSTATUS\tSIZE\tUPDATED\tCHECKED"); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w, "----\t------\t----\t-------\t-------"); err != nil {
+			return err
+		}
+		for _, f := range selected {
+			state, known := states[f.Name]
+			status, size := "ok", "-"
+			if info, err := os.Stat(filepath.Join(dir, f.FileName())); err != nil {
+				status = "missing"
+			} else {
+				size = fmt.Sprintf("%d", info.Size())
+				if !known {
+					status = "unmanaged"
+				} else if state.URL != f.URL {
+					status = "url changed"
+				}
+			}
+
+			if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				f.Name, status, size, formatTime(state.UpdatedAt), formatTime(state.CheckedAt)); err != nil {
+				return err
+			}
+		}
+		return w.Flush()
+	},
+}
+
+// feedsUpdateCmd downloads feeds that have changed.
+var feedsUpdateCmd = &cobra.Command{
+	Use:   "update [name...]",
+	Short: "Download new versions of configured feeds",
+	Long: `Download every configured feed, or only those named, into the data directory.
+
+Requests are conditional on the previous ETag and Last-Modified unless --force
+is given. Downloads are checksum-verified when the feed has a sha256 or
+checksum_url, and replace the existing file atomically.`,
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return invalidInput(err)
+		}
+
+		selected, err := feeds.Select(cfg.Feeds, args)
+		if err != nil {
+			return invalidInput(err)
+		}
+		if len(selected) == 0 {
+			return invalidInput(errors.New("no feeds configured"))
+		}
+
+		// Downloads can be large, so rely on cancellation rather than an overall timeout.
+		client, err := provider.NewHTTPClient(baseTransport(cfg.Network), 0)
+		if err != nil {
+			return invalidInput(err)
+		}
+
+		cmd.SilenceUsage = true
+
+		ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+
+		dir := provider.DataDir()
+		states, err := feeds.LoadState(dir)
+		if err != nil {
+			return err
+		}
+
+		manager := &feeds.Manager{Dir: dir, Client: client}
+		failed := 0
+		for _, f := range selected {
+			outcome, state, err := manager.Update(ctx, f, states[f.Name], forceUpdate)
+			if err != nil {
+				failed++
+				cmd.PrintErrf("%s: %v\n", f.Name, err)
+				if ctx.Err() != nil {
+					break
+				}
+				continue
+			}
+
+			states[f.Name] = state
+			if err := feeds.SaveState(dir, states); err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(cmd.OutOrStdout(), "%s: %s\n", f.Name, outcome); err != nil {
+				return err
+			}
+		}
+
+		switch {
+		case failed == len(selected):
+			return &ExitError{Code: ExitAllFailed, Err: errors.New("every feed failed to update")}
+		case failed > 0:
+			return &ExitError{Code: ExitPartialFailure, Err: fmt.Errorf("%d of %d feeds failed to update", failed, len(selected))}
+		}
+		return nil
+	},
+}
+
+// formatTime renders a feed timestamp for status output.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.Local().Format(time.DateTime)
+}
+
+func init() {
+	feedsUpdateCmd.Flags().BoolVar(&forceUpdate, "force", false, "Download even if the feed has not changed")
+
+	feedsCmd.AddCommand(feedsListCmd, feedsStatusCmd, feedsUpdateCmd)
+	rootCmd.AddCommand(feedsCmd)
+}
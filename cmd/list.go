@@ -15,16 +15,24 @@ var listCmd = &cobra.Command{
 	Args:  cobra.NoArgs,
 
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := loadConfig(); err != nil {
+			return err
+		}
+
 		providers := provider.All()
 		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
-		if _, err := fmt.Fprintln(w, "ID\tNAME"); err != nil {
+		if _, err := fmt.Fprintln(w, "ID\tNAME\tSTATUS"); err != nil {
 			return err
 		}
-		if _, err := fmt.Fprintln(w, "--\t----"); err != nil {
+		if _, err := fmt.Fprintln(w, "--\t----\t------"); err != nil {
 			return err
 		}
 		for _, p := range providers {
-			if _, err := fmt.Fprintf(w, "%s\t%s\n", p.ID(), p.Name()); err != nil {
+			status := "enabled"
+			if !provider.IsEnabled(p) {
+				status = "not configured"
+			}
+			if _, err := fmt.Fprintf(w, "%s\t%s\t%s\n", p.ID(), p.Name(), status); err != nil {
 				return err
 			}
 		}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dalryan/ip-enrich/internal/config"
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// baseTransport returns the transport settings shared by every outbound request.
+// Command-line flags take precedence over the config file.
+func baseTransport(cfg config.Network) provider.TransportConfig {
+	if proxyURL != "" {
+		cfg.Proxy = proxyURL
+	}
+	if caCert != "" {
+		cfg.CACert = caCert
+	}
+	if clientCert != "" {
+		cfg.ClientCert = clientCert
+	}
+	if clientKey != "" {
+		cfg.ClientKey = clientKey
+	}
+
+	return provider.TransportConfig{
+		ProxyURL:       cfg.Proxy,
+		CAFile:         cfg.CACert,
+		ClientCertFile: cfg.ClientCert,
+		ClientKeyFile:  cfg.ClientKey,
+	}
+}
+
+// networkOptions builds executor options for the configured proxy, CA bundle and client certificate.
+func networkOptions(cfg config.Network, timeout time.Duration) ([]provider.ExecutorOption, error) {
+	base := baseTransport(cfg)
+
+	client, err := provider.NewHTTPClient(base, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []provider.ExecutorOption{
+		provider.WithTimeout(timeout),
+		provider.WithHTTPClient(client),
+	}
+
+	for id, proxy := range cfg.ProviderProxies {
+		override := base
+		override.ProxyURL = proxy
+		// An explicit empty override means "no proxy", not "inherit the environment".
+		override.Direct = proxy == ""
+
+		c, err := provider.NewHTTPClient(override, timeout)
+		if err != nil {
+			return nil, fmt.Errorf("provider %s: %w", id, err)
+		}
+		opts = append(opts, provider.WithProviderHTTPClient(id, c))
+	}
+
+	return opts, nil
+}
@@ -7,6 +7,8 @@ import (
 	"net"
 	"os"
 	"os/signal"
+	"slices"
+	"sync"
 	"syscall"
 	"time"
 
@@ -20,6 +22,13 @@ var (
 	outputFormat   string
 	providerFilter []string
 	timeout        int
+	verbose        bool
+	failOn         []string
+	configPath     string
+	proxyURL       string
+	caCert         string
+	clientCert     string
+	clientKey      string
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -31,17 +40,30 @@ var rootCmd = &cobra.Command{
 Examples:
   ip-enrich 1.1.1.1
   ip-enrich 1.1.1.1 -p shodan,greynoise
-  ip-enrich -o json 8.8.8.8`,
+  ip-enrich -o json 8.8.8.8
+  ip-enrich --fail-on partial,malicious 8.8.8.8`,
 
-	Args: cobra.ExactArgs(1),
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+			return invalidInput(err)
+		}
+		return nil
+	},
 
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ip := args[0]
 
 		if net.ParseIP(ip) == nil {
-			return fmt.Errorf("'%s' is not a valid IP address", ip)
+			return invalidInput(fmt.Errorf("'%s' is not a valid IP address", ip))
+		}
+
+		if err := validateFailOn(failOn); err != nil {
+			return err
 		}
 
+		// Past this point errors are about the run, not the invocation.
+		cmd.SilenceUsage = true
+
 		ctx, cancel := context.WithCancel(cmd.Context())
 		defer cancel()
 
@@ -55,11 +77,46 @@ Examples:
 		if len(providerFilter) > 0 {
 			unknown := provider.Validate(providerFilter)
 			if len(unknown) > 0 {
+				if slices.Contains(failOn, failOnAnyError) {
+					return invalidInput(fmt.Errorf("unknown providers: %v", unknown))
+				}
 				cmd.PrintErrf("Warning: unknown providers ignored: %v\n", unknown)
 			}
 		}
 
-		return run(ctx, ip, providerFilter, outputFormat, timeout, cmd.OutOrStdout())
+		cfg, err := loadConfig()
+		if err != nil {
+			return invalidInput(err)
+		}
+
+		opts, err := networkOptions(cfg.Network, time.Duration(timeout)*time.Second)
+		if err != nil {
+			return invalidInput(err)
+		}
+
+		var logw io.Writer
+		if verbose {
+			logw = cmd.ErrOrStderr()
+		}
+
+		// Breaker state is carried across invocations so repeated failures open the breaker.
+		statePath := breakerStatePath()
+		states, err := loadBreakerStates(statePath)
+		if err != nil {
+			cmd.PrintErrf("Warning: ignoring saved circuit breaker state: %v\n", err)
+		}
+		executor := provider.NewExecutor(append(opts, provider.WithBreakerStates(states))...)
+
+		report, err := run(ctx, ip, providerFilter, outputFormat, timeout, executor, cmd.OutOrStdout(), logw)
+		if err != nil {
+			return err
+		}
+
+		if err := saveBreakerStates(statePath, executor.BreakerStates()); err != nil {
+			cmd.PrintErrf("Warning: failed to save circuit breaker state: %v\n", err)
+		}
+
+		return outcome(report, failOn)
 	},
 }
 
@@ -73,27 +130,61 @@ func init() {
 	rootCmd.PersistentFlags().StringSliceVarP(&providerFilter, "providers", "p", []string{}, "Comma-separated list of providers")
 	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "pretty", "Output format: json, pretty")
 	rootCmd.PersistentFlags().IntVarP(&timeout, "timeout", "t", 10, "HTTP timeout in seconds")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Print per-provider progress to stderr")
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "Config file (default is $XDG_CONFIG_HOME/ip-enrich/config.json)")
+	rootCmd.PersistentFlags().StringVar(&proxyURL, "proxy", "", "Proxy URL for outbound requests (http, https, socks5, socks5h)")
+	rootCmd.PersistentFlags().StringVar(&caCert, "ca-cert", "", "PEM CA bundle to trust in addition to the system roots")
+	rootCmd.PersistentFlags().StringVar(&clientCert, "client-cert", "", "PEM client certificate for mTLS")
+	rootCmd.PersistentFlags().StringVar(&clientKey, "client-key", "", "PEM client key for mTLS")
+	rootCmd.Flags().StringSliceVar(&failOn, "fail-on", []string{}, failOnUsage)
 }
 
-// run takes the list of providers and executes them
-func run(ctx context.Context, ip string, providerIDs []string, format string, timeoutSeconds int, w io.Writer) error {
-	providers := provider.Filter(providerIDs)
+// run takes the list of providers, executes them and writes the report.
+// If logw is non-nil, per-provider progress is written to it as results arrive.
+func run(ctx context.Context, ip string, providerIDs []string, format string, timeoutSeconds int, executor *provider.Executor, w io.Writer, logw io.Writer) (*output.Report, error) {
+	providers, implicit := provider.Filter(providerIDs)
 	if len(providers) == 0 {
-		return fmt.Errorf("no providers matched request")
+		return nil, invalidInput(fmt.Errorf("no providers matched request"))
+	}
+
+	formatter, err := output.GetFormatter(format, w)
+	if err != nil {
+		return nil, invalidInput(err)
 	}
 
 	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeoutSeconds)*time.Second)
 	defer cancel()
 
-	executor := provider.NewExecutor()
-	results := executor.Execute(ctx, ip, providers, nil)
+	var callback provider.ResultCallback
+	if logw != nil {
+		callback = progressCallback(executor, logw)
+	}
+	results := executor.Execute(ctx, ip, providers, callback)
+	for _, r := range results {
+		r.Implicit = implicit[r.ProviderID]
+	}
 
 	report := output.NewReport(ip, time.Now().UTC().Format(time.RFC3339), results)
 
-	formatter, err := output.GetFormatter(format, w)
-	if err != nil {
-		return err
+	if err := formatter.Format(report); err != nil {
+		return nil, err
 	}
 
-	return formatter.Format(report)
+	return report, nil
+}
+
+// progressCallback returns a ResultCallback that logs each result and its circuit breaker state.
+func progressCallback(executor *provider.Executor, w io.Writer) provider.ResultCallback {
+	var mu sync.Mutex
+	return func(r *provider.Result) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		state := executor.CircuitState(r.ProviderID)
+		if r.Error != "" {
+			_, _ = fmt.Fprintf(w, "[%s] error: %s (status %d, circuit %s)\n", r.ProviderID, r.Error, r.StatusCode, state)
+			return
+		}
+		_, _ = fmt.Fprintf(w, "[%s] ok (status %d, circuit %s)\n", r.ProviderID, r.StatusCode, state)
+	}
 }
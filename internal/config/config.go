@@ -0,0 +1,130 @@
+// Package config loads the optional ip-enrich configuration file.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Config is the top-level configuration file structure.
+type Config struct {
+	Network Network `json:"network"`
+
+	// DataDir holds downloaded feeds and databases. Relative local-data paths
+	// in provider settings are resolved against it.
+	DataDir string `json:"data_dir"`
+
+	// Feeds are the blocklists and databases managed by "ip-enrich feeds".
+	Feeds []Feed `json:"feeds"`
+
+	// Providers holds provider-specific settings keyed by provider ID.
+	// Each provider decodes its own entry.
+	Providers map[string]json.RawMessage `json:"providers"`
+}
+
+// Network configures how outbound requests reach providers.
+type Network struct {
+	// Proxy is an explicit proxy URL (http, https, socks5, socks5h).
+	Proxy string `json:"proxy"`
+
+	// CACert is a PEM bundle trusted in addition to the system roots.
+	CACert string `json:"ca_cert"`
+
+	// ClientCert and ClientKey are a PEM client certificate and key for mTLS.
+	ClientCert string `json:"client_cert"`
+	ClientKey  string `json:"client_key"`
+
+	// ProviderProxies overrides Proxy for individual providers, keyed by provider ID.
+	// An empty value sends that provider direct.
+	ProviderProxies map[string]string `json:"provider_proxies"`
+}
+
+// Feed is a remote file that "ip-enrich feeds update" keeps in the data directory.
+type Feed struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+
+	// File is the file name within the data directory (default: Name).
+	File string `json:"file,omitempty"`
+
+	// SHA256 pins the expected checksum of the downloaded file.
+	SHA256 string `json:"sha256,omitempty"`
+
+	// ChecksumURL points to a sha256sum-style file ("<hex>  <name>") for the download.
+	ChecksumURL string `json:"checksum_url,omitempty"`
+
+	// Extract is a glob selecting the member to keep from a .tar.gz download, e.g. "*.mmdb".
+	// Plain .gz downloads are decompressed automatically.
+	Extract string `json:"extract,omitempty"`
+}
+
+// FileName returns the feed's file name within the data directory.
+func (f Feed) FileName() string {
+	if f.File != "" {
+		return f.File
+	}
+	return f.Name
+}
+
+// DefaultDataDir returns the default data directory,
+// $XDG_DATA_HOME/ip-enrich or ~/.local/share/ip-enrich.
+func DefaultDataDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "ip-enrich"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "ip-enrich"), nil
+}
+
+// DefaultPath returns the default config file location, e.g. ~/.config/ip-enrich/config.json.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ip-enrich", "config.json"), nil
+}
+
+// Load reads the config file at path.
+// If path is empty the default location is used, and a missing default file yields an empty config.
+func Load(path string) (*Config, error) {
+	explicit := path != ""
+	if !explicit {
+		var err error
+		path, err = DefaultPath()
+		if err != nil {
+			return &Config{}, nil
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, fs.ErrNotExist) {
+			return &Config{}, nil
+		}
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	return &cfg, nil
+}
+
+// ResolveDataDir returns DataDir, falling back to DefaultDataDir.
+func (c *Config) ResolveDataDir() (string, error) {
+	if c.DataDir != "" {
+		return c.DataDir, nil
+	}
+	return DefaultDataDir()
+}
@@ -0,0 +1,364 @@
+// Package feeds downloads blocklists and databases into the local data directory.
+package feeds
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dalryan/ip-enrich/internal/config"
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// stateFile records download metadata for every feed, inside the data directory.
+const stateFile = "feeds.json"
+
+// State is what the last successful update learned about a feed.
+type State struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	SHA256       string    `json:"sha256"`
+	Size         int64     `json:"size"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	CheckedAt    time.Time `json:"checked_at"`
+}
+
+// Outcome describes what an update did.
+type Outcome string
+
+const (
+	// Updated means a new copy was downloaded and installed.
+	Updated Outcome = "updated"
+	// NotModified means the server reported the local copy is current.
+	NotModified Outcome = "not modified"
+)
+
+// Manager updates feeds in a data directory.
+type Manager struct {
+	Dir    string
+	Client *http.Client
+}
+
+// builtin are the feeds providers read by default, such as the Tor exit list.
+var builtin []config.Feed
+
+// Register adds a feed that is managed without being configured. It is meant to be called
+// from a provider's init function. A configured feed with the same name replaces it.
+func Register(feed config.Feed) {
+	builtin = append(builtin, feed)
+}
+
+// Select validates the configured feeds and returns those named, or all of them if names is empty.
+// Registered feeds are included unless a configured feed has the same name.
+func Select(configured []config.Feed, names []string) ([]config.Feed, error) {
+	feeds := slices.Clone(configured)
+	for _, b := range builtin {
+		if !slices.ContainsFunc(configured, func(f config.Feed) bool { return f.Name == b.Name }) {
+			feeds = append(feeds, b)
+		}
+	}
+
+	byName := make(map[string]config.Feed, len(feeds))
+	for _, f := range feeds {
+		if f.Name == "" || f.URL == "" {
+			return nil, errors.New("every feed needs a name and a url")
+		}
+		if _, dup := byName[f.Name]; dup {
+			return nil, fmt.Errorf("duplicate feed name %q", f.Name)
+		}
+		if !filepath.IsLocal(f.FileName()) || f.FileName() == stateFile {
+			return nil, fmt.Errorf("feed %s: file must be a relative path inside the data directory", f.Name)
+		}
+		byName[f.Name] = f
+	}
+
+	if len(names) == 0 {
+		return feeds, nil
+	}
+
+	selected := make([]config.Feed, 0, len(names))
+	for _, name := range names {
+		f, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown feed %q", name)
+		}
+		selected = append(selected, f)
+	}
+	return selected, nil
+}
+
+// LoadState reads the state file. A missing file yields an empty state.
+func LoadState(dir string) (map[string]State, error) {
+	states := make(map[string]State)
+
+	data, err := os.ReadFile(filepath.Join(dir, stateFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return states, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &states); err != nil {
+		return nil, fmt.Errorf("failed to parse feed state: %w", err)
+	}
+	return states, nil
+}
+
+// SaveState atomically writes the state file.
+func SaveState(dir string, states map[string]State) error {
+	data, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return WriteFile(dir, stateFile, data)
+}
+
+// WriteFile atomically replaces name within dir with data.
+func WriteFile(dir, name string, data []byte) error {
+	return writeAtomic(dir, name, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// Update fetches feed if it has changed since prev and installs it in the data directory.
+// Unless force is set, the request is conditional on prev's ETag and Last-Modified.
+// The returned state replaces prev; on error prev should be kept.
+func (m *Manager) Update(ctx context.Context, feed config.Feed, prev State, force bool) (Outcome, State, error) {
+	if !filepath.IsLocal(feed.FileName()) {
+		return "", prev, fmt.Errorf("feed %s: file must be a relative path inside the data directory", feed.Name)
+	}
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return "", prev, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	target := filepath.Join(m.Dir, feed.FileName())
+	req, err := newRequest(ctx, feed.URL)
+	if err != nil {
+		return "", prev, err
+	}
+
+	// Only send validators when the local copy is still there to be revalidated.
+	if _, statErr := os.Stat(target); !force && statErr == nil && prev.URL == feed.URL {
+		if prev.ETag != "" {
+			req.Header.Set("If-None-Match", prev.ETag)
+		}
+		if prev.LastModified != "" {
+			req.Header.Set("If-Modified-Since", prev.LastModified)
+		}
+	}
+
+	resp, err := m.Client.Do(req)
+	if err != nil {
+		return "", prev, fmt.Errorf("download failed: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	now := time.Now().UTC()
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		prev.CheckedAt = now
+		return NotModified, prev, nil
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return "", prev, fmt.Errorf("download failed: HTTP %d", resp.StatusCode)
+	}
+
+	expected := strings.ToLower(feed.SHA256)
+	if expected == "" && feed.ChecksumURL != "" {
+		expected, err = m.fetchChecksum(ctx, feed)
+		if err != nil {
+			return "", prev, err
+		}
+	}
+
+	// The checksum covers the file as downloaded, before any decompression.
+	hash := sha256.New()
+	body := io.TeeReader(resp.Body, hash)
+
+	var size int64
+	err = writeAtomic(m.Dir, feed.FileName(), func(w io.Writer) error {
+		var err error
+		size, err = decode(w, body, feed)
+		if err != nil {
+			return err
+		}
+
+		// Drain anything the decoder left unread so the checksum sees the whole download.
+		if _, err := io.Copy(io.Discard, body); err != nil {
+			return err
+		}
+
+		if sum := hex.EncodeToString(hash.Sum(nil)); expected != "" && sum != expected {
+			return fmt.Errorf("checksum mismatch: got %s, want %s", sum, expected)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", prev, err
+	}
+
+	return Updated, State{
+		URL:          feed.URL,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		SHA256:       hex.EncodeToString(hash.Sum(nil)),
+		Size:         size,
+		UpdatedAt:    now,
+		CheckedAt:    now,
+	}, nil
+}
+
+// fetchChecksum downloads a sha256sum-style file and returns the digest for the feed.
+// A file with a single bare digest is also accepted.
+func (m *Manager) fetchChecksum(ctx context.Context, feed config.Feed) (string, error) {
+	req, err := newRequest(ctx, feed.ChecksumURL)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := m.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("checksum download failed: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("checksum download failed: HTTP %d", resp.StatusCode)
+	}
+
+	name := path.Base(strings.SplitN(feed.URL, "?", 2)[0])
+	var only string
+	lines := 0
+
+	scanner := bufio.NewScanner(io.LimitReader(resp.Body, 1<<20))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		lines++
+		only = fields[0]
+		// sha256sum marks binary mode with a leading "*".
+		if len(fields) > 1 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read checksum: %w", err)
+	}
+
+	if lines == 1 && len(only) == sha256.Size*2 {
+		return strings.ToLower(only), nil
+	}
+	return "", fmt.Errorf("no checksum for %s in %s", name, feed.ChecksumURL)
+}
+
+// newRequest creates a GET request for a feed download.
+func newRequest(ctx context.Context, url string) (*http.Request, error) {
+	req, err := provider.NewRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "*/*")
+	return req, nil
+}
+
+// decode writes the feed's content to w, unpacking .gz and .tar.gz downloads.
+func decode(w io.Writer, r io.Reader, feed config.Feed) (int64, error) {
+	name := strings.ToLower(path.Base(strings.SplitN(feed.URL, "?", 2)[0]))
+	tarball := feed.Extract != "" || strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz")
+
+	if !tarball && !strings.HasSuffix(name, ".gz") {
+		return io.Copy(w, r)
+	}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decompress: %w", err)
+	}
+	defer func() {
+		_ = gz.Close()
+	}()
+
+	if !tarball {
+		return io.Copy(w, gz)
+	}
+
+	pattern := feed.Extract
+	if pattern == "" {
+		pattern = feed.FileName()
+	}
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return 0, fmt.Errorf("no member matching %q in archive", pattern)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if ok, _ := path.Match(pattern, path.Base(hdr.Name)); ok {
+			return io.Copy(w, tr)
+		}
+	}
+}
+
+// writeAtomic writes a file via a temporary file in the same directory and renames it into place,
+// so readers never see a partial file. Nothing is replaced if write fails.
+func writeAtomic(dir, name string, write func(io.Writer) error) error {
+	target := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	// After a successful rename the temporary name no longer exists and Remove is a no-op.
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	err = write(tmp)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
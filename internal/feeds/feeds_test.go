@@ -0,0 +1,385 @@
+package feeds
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dalryan/ip-enrich/internal/config"
+)
+
+// newTestManager returns a manager for a fresh data directory and a server answering with handler.
+func newTestManager(t *testing.T, handler http.Handler) (*Manager, *httptest.Server) {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return &Manager{Dir: filepath.Join(t.TempDir(), "data"), Client: srv.Client()}, srv
+}
+
+// serveFile answers every request with body, and 404 for anything under /missing.
+func serveFile(body []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/missing") {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(body)
+	})
+}
+
+// assertDir checks that dir holds exactly the named entries, so no temporary files were left behind.
+func assertDir(t *testing.T, dir string, want ...string) {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Name())
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("%s contains %v, want %v", dir, got, want)
+	}
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestUpdateChecksum(t *testing.T) {
+	body := []byte("192.0.2.1\n")
+
+	tests := []struct {
+		name     string
+		sha256   string
+		checksum string
+		wantErr  string
+	}{
+		{name: "no checksum"},
+		{name: "pinned", sha256: strings.ToUpper(sha256Hex(body))},
+		{name: "pinned mismatch", sha256: sha256Hex([]byte("other")), wantErr: "checksum mismatch"},
+		{name: "checksum file", checksum: sha256Hex([]byte("x")) + "  other.txt\n" + sha256Hex(body) + " *list.txt\n"},
+		{name: "bare checksum", checksum: sha256Hex(body) + "\n"},
+		{name: "checksum file mismatch", checksum: sha256Hex([]byte("other")) + "  list.txt\n", wantErr: "checksum mismatch"},
+		{name: "checksum file without entry", checksum: sha256Hex(body) + "  other.txt\n" + sha256Hex(body) + "  more.txt\n", wantErr: "no checksum for list.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, srv := newTestManager(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/list.txt.sha256" {
+					_, _ = w.Write([]byte(tt.checksum))
+					return
+				}
+				_, _ = w.Write(body)
+			}))
+
+			// An existing copy must survive a failed update untouched.
+			if err := WriteFile(m.Dir, "list", []byte("old\n")); err != nil {
+				t.Fatal(err)
+			}
+
+			feed := config.Feed{Name: "list", URL: srv.URL + "/list.txt", SHA256: tt.sha256}
+			if tt.checksum != "" {
+				feed.ChecksumURL = srv.URL + "/list.txt.sha256"
+			}
+			outcome, state, err := m.Update(context.Background(), feed, State{}, false)
+
+			want := body
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				want = []byte("old\n")
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if outcome != Updated || state.SHA256 != sha256Hex(body) || state.Size != int64(len(body)) {
+					t.Errorf("got %s %+v", outcome, state)
+				}
+			}
+
+			got, err := os.ReadFile(filepath.Join(m.Dir, "list"))
+			if err != nil || !bytes.Equal(got, want) {
+				t.Errorf("file = %q (%v), want %q", got, err, want)
+			}
+			assertDir(t, m.Dir, "list")
+		})
+	}
+}
+
+func TestUpdateFailureKeepsFile(t *testing.T) {
+	tests := []struct {
+		name string
+		feed func(url string) config.Feed
+	}{
+		{name: "HTTP error", feed: func(url string) config.Feed { return config.Feed{Name: "list", URL: url + "/missing"} }},
+		{name: "checksum download fails", feed: func(url string) config.Feed {
+			return config.Feed{Name: "list", URL: url + "/list", ChecksumURL: url + "/missing.sha256"}
+		}},
+		{name: "corrupt gzip", feed: func(url string) config.Feed { return config.Feed{Name: "list", URL: url + "/list.gz"} }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, srv := newTestManager(t, serveFile([]byte("not gzip")))
+			if err := WriteFile(m.Dir, "list", []byte("old\n")); err != nil {
+				t.Fatal(err)
+			}
+
+			prev := State{URL: "previous"}
+			_, state, err := m.Update(context.Background(), tt.feed(srv.URL), prev, false)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if state != prev {
+				t.Errorf("state = %+v, want the previous state", state)
+			}
+			if got, _ := os.ReadFile(filepath.Join(m.Dir, "list")); string(got) != "old\n" {
+				t.Errorf("file = %q, want it unchanged", got)
+			}
+			assertDir(t, m.Dir, "list")
+		})
+	}
+}
+
+func TestUpdateConditional(t *testing.T) {
+	const etag, lastModified = `"v1"`, "Mon, 01 Jan 2024 00:00:00 GMT"
+	var gotHeaders http.Header
+
+	m, srv := newTestManager(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeaders = r.Header.Clone()
+		if r.Header.Get("If-None-Match") == etag || r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", lastModified)
+		_, _ = w.Write([]byte("192.0.2.1\n"))
+	}))
+	feed := config.Feed{Name: "list", URL: srv.URL + "/list"}
+	ctx := context.Background()
+
+	outcome, state, err := m.Update(ctx, feed, State{}, false)
+	if err != nil || outcome != Updated {
+		t.Fatalf("first update: %s, %v", outcome, err)
+	}
+	if state.ETag != etag || state.LastModified != lastModified {
+		t.Fatalf("state = %+v, want the validators recorded", state)
+	}
+
+	outcome, next, err := m.Update(ctx, feed, state, false)
+	if err != nil || outcome != NotModified {
+		t.Fatalf("second update: %s, %v", outcome, err)
+	}
+	if gotHeaders.Get("If-None-Match") != etag || gotHeaders.Get("If-Modified-Since") != lastModified {
+		t.Errorf("validators not sent: %v", gotHeaders)
+	}
+	if next.SHA256 != state.SHA256 || next.CheckedAt.Before(state.CheckedAt) {
+		t.Errorf("state after 304 = %+v", next)
+	}
+
+	tests := []struct {
+		name   string
+		feed   config.Feed
+		force  bool
+		remove bool
+	}{
+		{name: "forced", feed: feed, force: true},
+		{name: "URL changed", feed: config.Feed{Name: "list", URL: srv.URL + "/list?v=2"}},
+		{name: "local copy missing", feed: feed, remove: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.remove {
+				if err := os.Remove(filepath.Join(m.Dir, "list")); err != nil {
+					t.Fatal(err)
+				}
+			}
+			outcome, _, err := m.Update(ctx, tt.feed, state, tt.force)
+			if err != nil || outcome != Updated {
+				t.Errorf("got %s, %v; want a full download", outcome, err)
+			}
+			if gotHeaders.Get("If-None-Match") != "" || gotHeaders.Get("If-Modified-Since") != "" {
+				t.Errorf("validators sent: %v", gotHeaders)
+			}
+		})
+	}
+}
+
+// tarMember is a file in a test archive.
+type tarMember struct {
+	name     string
+	body     string
+	typeflag byte
+	linkname string
+}
+
+func buildTarGz(t *testing.T, members []tarMember) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, m := range members {
+		typeflag := m.typeflag
+		if typeflag == 0 {
+			typeflag = tar.TypeReg
+		}
+		hdr := &tar.Header{Name: m.name, Mode: 0o644, Size: int64(len(m.body)), Typeflag: typeflag, Linkname: m.linkname}
+		if typeflag != tar.TypeReg {
+			hdr.Size = 0
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(m.body[:hdr.Size])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestUpdateExtract(t *testing.T) {
+	tests := []struct {
+		name    string
+		members []tarMember
+		extract string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "member by pattern",
+			members: []tarMember{{name: "GeoLite2-City_20240101/COPYRIGHT.txt", body: "c"}, {name: "GeoLite2-City_20240101/GeoLite2-City.mmdb", body: "db"}},
+			extract: "*.mmdb",
+			want:    "db",
+		},
+		{
+			name:    "member named like the file",
+			members: []tarMember{{name: "other.mmdb", body: "other"}, {name: "dir/city.mmdb", body: "db"}},
+			want:    "db",
+		},
+		{
+			name:    "path escape is written to the feed file only",
+			members: []tarMember{{name: "../../../evil.mmdb", body: "evil"}},
+			extract: "*.mmdb",
+			want:    "evil",
+		},
+		{
+			name: "links and directories are skipped",
+			members: []tarMember{
+				{name: "dir/", typeflag: tar.TypeDir},
+				{name: "link.mmdb", typeflag: tar.TypeSymlink, linkname: "/etc/passwd"},
+				{name: "hard.mmdb", typeflag: tar.TypeLink, linkname: "../../etc/passwd"},
+				{name: "real.mmdb", body: "db"},
+			},
+			extract: "*.mmdb",
+			want:    "db",
+		},
+		{
+			name:    "no matching member",
+			members: []tarMember{{name: "README", body: "r"}},
+			extract: "*.mmdb",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, srv := newTestManager(t, serveFile(buildTarGz(t, tt.members)))
+			feed := config.Feed{Name: "city", File: "city.mmdb", URL: srv.URL + "/GeoLite2-City.tar.gz", Extract: tt.extract}
+
+			_, _, err := m.Update(context.Background(), feed, State{}, false)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+
+			// Nothing may be written outside the data directory.
+			assertDir(t, filepath.Dir(m.Dir), "data")
+			if tt.wantErr {
+				assertDir(t, m.Dir)
+				return
+			}
+			assertDir(t, m.Dir, "city.mmdb")
+			if got, _ := os.ReadFile(filepath.Join(m.Dir, "city.mmdb")); string(got) != tt.want {
+				t.Errorf("file = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateGzip(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, _ = gz.Write([]byte("192.0.2.1\n"))
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	m, srv := newTestManager(t, serveFile(buf.Bytes()))
+	feed := config.Feed{Name: "list", URL: srv.URL + "/list.txt.gz", SHA256: sha256Hex(buf.Bytes())}
+	_, state, err := m.Update(context.Background(), feed, State{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(m.Dir, "list")); string(got) != "192.0.2.1\n" || state.Size != 10 {
+		t.Errorf("file = %q, size %d", got, state.Size)
+	}
+}
+
+func TestUpdateRejectsEscapingFile(t *testing.T) {
+	m, srv := newTestManager(t, serveFile([]byte("x")))
+	for _, file := range []string{"../outside", "/etc/outside", ""} {
+		feed := config.Feed{Name: "list", File: file, URL: srv.URL}
+		if file == "" {
+			feed.Name = "../outside"
+		}
+		if _, _, err := m.Update(context.Background(), feed, State{}, false); err == nil {
+			t.Errorf("file %q was accepted", file)
+		}
+	}
+	assertDir(t, filepath.Dir(m.Dir))
+}
+
+func TestSelectIncludesRegistered(t *testing.T) {
+	saved := builtin
+	t.Cleanup(func() { builtin = saved })
+	builtin = nil
+	Register(config.Feed{Name: "builtin", URL: "https://example.com/builtin"})
+	Register(config.Feed{Name: "overridden", URL: "https://example.com/default"})
+
+	configured := []config.Feed{{Name: "overridden", URL: "https://mirror.example.com/list"}}
+
+	all, err := Select(configured, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || all[0].URL != "https://mirror.example.com/list" || all[1].Name != "builtin" {
+		t.Errorf("Select = %+v", all)
+	}
+
+	named, err := Select(configured, []string{"builtin"})
+	if err != nil || len(named) != 1 || named[0].Name != "builtin" {
+		t.Errorf("Select(builtin) = %+v, %v", named, err)
+	}
+}
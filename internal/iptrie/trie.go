@@ -0,0 +1,115 @@
+// Package iptrie matches IP addresses against large sets of IPs and CIDR prefixes.
+package iptrie
+
+import (
+	"net/netip"
+)
+
+// Trie maps IP prefixes to values and finds every prefix covering an address.
+// Single-host entries (/32, /128) are kept in a map, since they dominate most
+// blocklists; shorter prefixes go in a binary trie per address family.
+// A Trie is not safe for concurrent writes, but may be read concurrently once built.
+type Trie[V any] struct {
+	hosts map[netip.Addr][]V
+	v4    *node[V]
+	v6    *node[V]
+	size  int
+}
+
+// node is a binary trie node. values holds the entries whose prefix ends here.
+type node[V any] struct {
+	children [2]*node[V]
+	values   []V
+}
+
+// New creates an empty Trie.
+func New[V any]() *Trie[V] {
+	return &Trie[V]{
+		hosts: make(map[netip.Addr][]V),
+		v4:    &node[V]{},
+		v6:    &node[V]{},
+	}
+}
+
+// Len returns the number of entries inserted.
+func (t *Trie[V]) Len() int {
+	return t.size
+}
+
+// Insert adds value under prefix. IPv4-mapped IPv6 prefixes are stored as IPv4.
+func (t *Trie[V]) Insert(prefix netip.Prefix, value V) {
+	prefix = normalize(prefix)
+	t.size++
+
+	if prefix.IsSingleIP() {
+		t.hosts[prefix.Addr()] = append(t.hosts[prefix.Addr()], value)
+		return
+	}
+
+	n := t.root(prefix.Addr())
+	addr := prefix.Addr().AsSlice()
+	for i := range prefix.Bits() {
+		b := bit(addr, i)
+		if n.children[b] == nil {
+			n.children[b] = &node[V]{}
+		}
+		n = n.children[b]
+	}
+	n.values = append(n.values, value)
+}
+
+// Match returns the values of every prefix containing addr,
+// from the least to the most specific.
+func (t *Trie[V]) Match(addr netip.Addr) []V {
+	addr = addr.Unmap()
+
+	var matches []V
+	n := t.root(addr)
+	raw := addr.AsSlice()
+	for i := 0; n != nil; i++ {
+		matches = append(matches, n.values...)
+		if i == len(raw)*8 {
+			break
+		}
+		n = n.children[bit(raw, i)]
+	}
+
+	return append(matches, t.hosts[addr]...)
+}
+
+// root returns the trie for addr's address family.
+func (t *Trie[V]) root(addr netip.Addr) *node[V] {
+	if addr.Is4() {
+		return t.v4
+	}
+	return t.v6
+}
+
+// normalize masks the prefix and unmaps IPv4-mapped IPv6 addresses.
+func normalize(prefix netip.Prefix) netip.Prefix {
+	addr := prefix.Addr()
+	bits := prefix.Bits()
+	if addr.Is4In6() {
+		addr = addr.Unmap()
+		bits = max(bits-96, 0)
+	}
+	return netip.PrefixFrom(addr, bits).Masked()
+}
+
+// bit returns bit i (from the most significant) of addr.
+func bit(addr []byte, i int) int {
+	return int(addr[i/8]>>(7-uint(i%8))) & 1
+}
+
+// ParsePrefix parses a CIDR prefix or a bare IP address (as a single-host prefix).
+func ParsePrefix(s string) (netip.Prefix, error) {
+	if prefix, err := netip.ParsePrefix(s); err == nil {
+		return prefix, nil
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
@@ -0,0 +1,101 @@
+package iptrie
+
+import (
+	"net/netip"
+	"slices"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	trie := New[string]()
+	for _, entry := range []string{
+		"0.0.0.0/0",
+		"10.0.0.0/8",
+		"10.1.0.0/16",
+		"10.1.2.0/24",
+		"10.1.2.3",
+		"192.0.2.0/24",
+		"192.0.2.0/24", // duplicate entries are both reported
+		"::ffff:198.51.100.0/120",
+		"::ffff:203.0.113.7",
+		"2001:db8::/32",
+		"2001:db8:1::/48",
+		"2001:db8:1::1",
+	} {
+		prefix, err := ParsePrefix(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		trie.Insert(prefix, entry)
+	}
+
+	tests := []struct {
+		addr string
+		want []string
+	}{
+		{addr: "10.1.2.3", want: []string{"0.0.0.0/0", "10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24", "10.1.2.3"}},
+		{addr: "10.1.2.4", want: []string{"0.0.0.0/0", "10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24"}},
+		{addr: "10.2.0.1", want: []string{"0.0.0.0/0", "10.0.0.0/8"}},
+		{addr: "11.0.0.1", want: []string{"0.0.0.0/0"}},
+		{addr: "192.0.2.200", want: []string{"0.0.0.0/0", "192.0.2.0/24", "192.0.2.0/24"}},
+		// IPv4-mapped entries are stored as IPv4, and mapped lookups find IPv4 entries.
+		{addr: "198.51.100.9", want: []string{"0.0.0.0/0", "::ffff:198.51.100.0/120"}},
+		{addr: "203.0.113.7", want: []string{"0.0.0.0/0", "::ffff:203.0.113.7"}},
+		{addr: "::ffff:10.1.2.3", want: []string{"0.0.0.0/0", "10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24", "10.1.2.3"}},
+		// IPv4 entries never match IPv6 addresses, including IPv4-compatible ones.
+		{addr: "::10.1.2.3", want: nil},
+		{addr: "2001:db8:1::1", want: []string{"2001:db8::/32", "2001:db8:1::/48", "2001:db8:1::1"}},
+		{addr: "2001:db8:2::1", want: []string{"2001:db8::/32"}},
+		{addr: "2001:db9::1", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			got := trie.Match(netip.MustParseAddr(tt.addr))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Match(%s) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+
+	if trie.Len() != 12 {
+		t.Errorf("Len() = %d, want 12", trie.Len())
+	}
+}
+
+func TestInsertMasksPrefix(t *testing.T) {
+	trie := New[string]()
+	trie.Insert(netip.MustParsePrefix("10.1.2.3/8"), "unmasked")
+
+	if got := trie.Match(netip.MustParseAddr("10.200.0.1")); !slices.Equal(got, []string{"unmasked"}) {
+		t.Errorf("Match = %v, want [unmasked]", got)
+	}
+}
+
+func TestParsePrefix(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "192.0.2.0/24", want: "192.0.2.0/24"},
+		{in: "192.0.2.1", want: "192.0.2.1/32"},
+		{in: "2001:db8::1", want: "2001:db8::1/128"},
+		{in: "2001:db8::/32", want: "2001:db8::/32"},
+		{in: "192.0.2.0/33", wantErr: true},
+		{in: "192.0.2", wantErr: true},
+		{in: "example.com", wantErr: true},
+		{in: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePrefix(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePrefix(%q) err = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got.String() != tt.want {
+			t.Errorf("ParsePrefix(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package mmdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// Data section field types.
+const (
+	typeExtended = 0
+	typePointer  = 1
+	typeString   = 2
+	typeDouble   = 3
+	typeBytes    = 4
+	typeUint16   = 5
+	typeUint32   = 6
+	typeMap      = 7
+	typeInt32    = 8
+	typeUint64   = 9
+	typeUint128  = 10
+	typeArray    = 11
+	typeBool     = 14
+	typeFloat    = 15
+)
+
+// maxDepth limits how deeply maps and arrays may nest, so a map that points back
+// to itself cannot recurse forever. libmaxminddb uses the same limit.
+const maxDepth = 512
+
+var (
+	// errTruncated is returned when a field runs past the end of the section.
+	errTruncated = errors.New("unexpected end of data section")
+	// errPointerToPointer is returned for a pointer whose target is another pointer,
+	// which the format forbids and which could otherwise loop.
+	errPointerToPointer = errors.New("invalid pointer: target is a pointer")
+	// errTooDeep is returned when maps and arrays nest deeper than maxDepth.
+	errTooDeep = fmt.Errorf("data structures nested deeper than %d levels", maxDepth)
+)
+
+// decoder decodes fields from a data section. Offsets are relative to buf.
+type decoder struct {
+	buf []byte
+}
+
+// decode decodes the field at offset into plain Go values
+// (map[string]any, []any, string, float64, uint64, int64, bool, []byte)
+// and returns the offset of the following field.
+func (d *decoder) decode(offset int) (any, int, error) {
+	return d.decodeAt(offset, 0)
+}
+
+// decodeAt decodes the field at offset, which is nested depth maps and arrays deep.
+func (d *decoder) decodeAt(offset, depth int) (any, int, error) {
+	if offset < 0 || offset >= len(d.buf) {
+		return nil, 0, errTruncated
+	}
+
+	ctrl := d.buf[offset]
+	offset++
+	typ := int(ctrl >> 5)
+
+	if typ == typePointer {
+		ptr, next, err := d.pointer(ctrl, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		if ptr < len(d.buf) && d.buf[ptr]>>5 == typePointer {
+			return nil, 0, errPointerToPointer
+		}
+		value, _, err := d.decodeAt(ptr, depth)
+		return value, next, err
+	}
+
+	if typ == typeExtended {
+		if offset >= len(d.buf) {
+			return nil, 0, errTruncated
+		}
+		typ = 7 + int(d.buf[offset])
+		offset++
+	}
+
+	size, offset, err := d.size(ctrl, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch typ {
+	case typeMap, typeArray:
+		if depth >= maxDepth {
+			return nil, 0, errTooDeep
+		}
+		// Every entry takes at least one byte, so a larger size can only be corrupt.
+		if size > len(d.buf)-offset {
+			return nil, 0, errTruncated
+		}
+		if typ == typeMap {
+			return d.decodeMap(size, offset, depth+1)
+		}
+		return d.decodeArray(size, offset, depth+1)
+	case typeBool:
+		return size != 0, offset, nil
+	}
+
+	if offset+size > len(d.buf) {
+		return nil, 0, errTruncated
+	}
+	payload := d.buf[offset : offset+size]
+	next := offset + size
+
+	switch typ {
+	case typeString:
+		return string(payload), next, nil
+	case typeBytes:
+		return append([]byte(nil), payload...), next, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, fmt.Errorf("invalid double size: %d", size)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(payload)), next, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, fmt.Errorf("invalid float size: %d", size)
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(payload))), next, nil
+	case typeUint16, typeUint32, typeUint64:
+		return unsigned(payload), next, nil
+	case typeInt32:
+		return int64(int32(uint32(unsigned(payload)))), next, nil
+	case typeUint128:
+		return new(big.Int).SetBytes(payload).String(), next, nil
+	default:
+		return nil, 0, fmt.Errorf("unsupported data type: %d", typ)
+	}
+}
+
+// size decodes a field's payload size from the control byte and any size bytes.
+func (d *decoder) size(ctrl byte, offset int) (int, int, error) {
+	size := int(ctrl & 0x1f)
+	if size < 29 {
+		return size, offset, nil
+	}
+
+	n := size - 28
+	if offset+n > len(d.buf) {
+		return 0, 0, errTruncated
+	}
+	extra := int(unsigned(d.buf[offset : offset+n]))
+
+	switch size {
+	case 29:
+		size = 29 + extra
+	case 30:
+		size = 285 + extra
+	default:
+		size = 65821 + extra
+	}
+	return size, offset + n, nil
+}
+
+// pointer decodes a pointer field and returns its target and the offset after it.
+func (d *decoder) pointer(ctrl byte, offset int) (int, int, error) {
+	n := int((ctrl>>3)&0x3) + 1
+	if offset+n > len(d.buf) {
+		return 0, 0, errTruncated
+	}
+	b := d.buf[offset : offset+n]
+	vvv := int(ctrl & 0x7)
+
+	var ptr int
+	switch n {
+	case 1:
+		ptr = vvv<<8 | int(b[0])
+	case 2:
+		ptr = (vvv<<16 | int(unsigned(b))) + 2048
+	case 3:
+		ptr = (vvv<<24 | int(unsigned(b))) + 526336
+	default:
+		ptr = int(unsigned(b))
+	}
+	return ptr, offset + n, nil
+}
+
+// decodeMap decodes size key/value pairs starting at offset, at the given depth.
+func (d *decoder) decodeMap(size, offset, depth int) (any, int, error) {
+	m := make(map[string]any, size)
+	for range size {
+		key, next, err := d.decodeAt(offset, depth)
+		if err != nil {
+			return nil, 0, err
+		}
+		k, ok := key.(string)
+		if !ok {
+			return nil, 0, fmt.Errorf("map key is %T, not string", key)
+		}
+
+		value, next, err := d.decodeAt(next, depth)
+		if err != nil {
+			return nil, 0, err
+		}
+		m[k] = value
+		offset = next
+	}
+	return m, offset, nil
+}
+
+// decodeArray decodes size elements starting at offset, at the given depth.
+func (d *decoder) decodeArray(size, offset, depth int) (any, int, error) {
+	a := make([]any, 0, size)
+	for range size {
+		value, next, err := d.decodeAt(offset, depth)
+		if err != nil {
+			return nil, 0, err
+		}
+		a = append(a, value)
+		offset = next
+	}
+	return a, offset, nil
+}
+
+// unsigned decodes a big-endian unsigned integer of up to 8 bytes.
+func unsigned(b []byte) uint64 {
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v
+}
@@ -0,0 +1,85 @@
+package mmdb
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name     string
+		buf      []byte
+		offset   int
+		want     any
+		wantNext int
+	}{
+		{name: "string", buf: []byte{0x42, 'h', 'i'}, want: "hi", wantNext: 3},
+		{name: "uint16", buf: []byte{0xa2, 0x01, 0x00}, want: uint64(256), wantNext: 3},
+		{name: "int32", buf: []byte{0x04, 0x01, 0xff, 0xff, 0xff, 0xff}, want: int64(-1), wantNext: 6},
+		{name: "bool", buf: []byte{0x01, 0x07}, want: true, wantNext: 2},
+		{name: "long string", buf: append([]byte{0x5d, 0x01}, bytes.Repeat([]byte("a"), 30)...), want: string(bytes.Repeat([]byte("a"), 30)), wantNext: 32},
+		{name: "array", buf: []byte{0x02, 0x04, 0x41, 'a', 0x41, 'b'}, want: []any{"a", "b"}, wantNext: 6},
+		{name: "map", buf: []byte{0xe1, 0x41, 'k', 0x41, 'v'}, want: map[string]any{"k": "v"}, wantNext: 5},
+		{
+			// The pointer's target is decoded, but decoding continues after the pointer.
+			name: "pointer", buf: []byte{0x42, 'h', 'i', 0x20, 0x00}, offset: 3, want: "hi", wantNext: 5,
+		},
+		{
+			name: "map of pointers", buf: []byte{0x41, 'v', 0xe2, 0x41, 'a', 0x20, 0x00, 0x41, 'b', 0x20, 0x00},
+			offset: 2, want: map[string]any{"a": "v", "b": "v"}, wantNext: 11,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := decoder{buf: tt.buf}
+			got, next, err := d.decode(tt.offset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) || next != tt.wantNext {
+				t.Errorf("got %#v, next %d; want %#v, next %d", got, next, tt.want, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	// nestedArrays is maxDepth+1 single-element arrays around a string.
+	var nestedArrays []byte
+	for range maxDepth + 1 {
+		nestedArrays = append(nestedArrays, 0x01, 0x04)
+	}
+	nestedArrays = append(nestedArrays, 0x41, 'x')
+
+	tests := []struct {
+		name    string
+		buf     []byte
+		wantErr error
+	}{
+		{name: "empty", buf: nil, wantErr: errTruncated},
+		{name: "truncated string", buf: []byte{0x45, 'a', 'b'}, wantErr: errTruncated},
+		{name: "missing extended type", buf: []byte{0x01}, wantErr: errTruncated},
+		{name: "missing size bytes", buf: []byte{0x5d}, wantErr: errTruncated},
+		{name: "missing pointer bytes", buf: []byte{0x28, 0x00}, wantErr: errTruncated},
+		{name: "pointer past end", buf: []byte{0x20, 0x10}, wantErr: errTruncated},
+		{name: "map larger than section", buf: []byte{0xfc, 0x41, 'a'}, wantErr: errTruncated},
+		{name: "array larger than section", buf: []byte{0x1d, 0x04, 0xff}, wantErr: errTruncated},
+		{name: "map missing value", buf: []byte{0xe1, 0x41, 'k'}, wantErr: errTruncated},
+		{name: "pointer to itself", buf: []byte{0x20, 0x00}, wantErr: errPointerToPointer},
+		{name: "pointer to pointer", buf: []byte{0x20, 0x02, 0x20, 0x00}, wantErr: errPointerToPointer},
+		{name: "map containing itself", buf: []byte{0xe1, 0x41, 'a', 0x20, 0x00}, wantErr: errTooDeep},
+		{name: "nested too deeply", buf: nestedArrays, wantErr: errTooDeep},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := decoder{buf: tt.buf}
+			if _, _, err := d.decode(0); !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
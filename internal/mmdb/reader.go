@@ -0,0 +1,187 @@
+// Package mmdb reads MaxMind DB (.mmdb) files such as GeoLite2 and DB-IP databases.
+//
+// It implements the subset of the MaxMind DB format needed for lookups:
+// the binary search tree and the data section decoder.
+// See https://maxmind.github.io/MaxMind-DB/ for the specification.
+package mmdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+)
+
+// metadataMarker precedes the metadata map at the end of every database.
+var metadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// dataSectionSeparator is the number of zero bytes between the search tree and the data section.
+const dataSectionSeparator = 16
+
+// Metadata describes a database.
+type Metadata struct {
+	DatabaseType string            `json:"database_type"`
+	Description  map[string]string `json:"description"`
+	IPVersion    uint              `json:"ip_version"`
+	Languages    []string          `json:"languages"`
+	NodeCount    uint              `json:"node_count"`
+	RecordSize   uint              `json:"record_size"`
+	BuildEpoch   uint64            `json:"build_epoch"`
+	MajorVersion uint              `json:"binary_format_major_version"`
+}
+
+// Reader looks up IPs in an in-memory database.
+type Reader struct {
+	Metadata Metadata
+
+	tree      []byte
+	data      decoder
+	ipv4Start uint
+}
+
+// Open reads the database at path into memory.
+func Open(path string) (*Reader, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return FromBytes(buf)
+}
+
+// FromBytes parses a database held in memory.
+func FromBytes(buf []byte) (*Reader, error) {
+	idx := bytes.LastIndex(buf, metadataMarker)
+	if idx == -1 {
+		return nil, errors.New("invalid MaxMind DB: metadata marker not found")
+	}
+
+	meta := decoder{buf: buf[idx+len(metadataMarker):]}
+	raw, _, err := meta.decode(0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MaxMind DB metadata: %w", err)
+	}
+
+	r := &Reader{}
+	if err := convert(raw, &r.Metadata); err != nil {
+		return nil, fmt.Errorf("invalid MaxMind DB metadata: %w", err)
+	}
+
+	switch r.Metadata.RecordSize {
+	case 24, 28, 32:
+	default:
+		return nil, fmt.Errorf("unsupported MaxMind DB record size: %d", r.Metadata.RecordSize)
+	}
+
+	treeSize := int(r.Metadata.RecordSize*2/8) * int(r.Metadata.NodeCount)
+	if treeSize+dataSectionSeparator > idx {
+		return nil, errors.New("invalid MaxMind DB: search tree exceeds file size")
+	}
+
+	r.tree = buf[:treeSize]
+	r.data = decoder{buf: buf[treeSize+dataSectionSeparator : idx]}
+
+	// IPv4 addresses live under ::/96 in IPv6 databases.
+	if r.Metadata.IPVersion == 6 {
+		node := uint(0)
+		for i := 0; i < 96 && node < r.Metadata.NodeCount; i++ {
+			node = r.readNode(node, 0)
+		}
+		r.ipv4Start = node
+	}
+
+	return r, nil
+}
+
+// Lookup finds the record for ip and decodes it into v, which is populated as if
+// the record were JSON (use `json` struct tags matching the database's keys).
+// It returns the prefix length of the matching network and whether a record was found.
+func (r *Reader) Lookup(ip net.IP, v any) (int, bool, error) {
+	record, prefixLen, err := r.find(ip)
+	if err != nil || record == 0 {
+		return prefixLen, false, err
+	}
+
+	if record < r.Metadata.NodeCount+dataSectionSeparator {
+		return prefixLen, false, errors.New("invalid MaxMind DB: record points into the data section separator")
+	}
+	offset := int(record - r.Metadata.NodeCount - dataSectionSeparator)
+	raw, _, err := r.data.decode(offset)
+	if err != nil {
+		return prefixLen, false, err
+	}
+
+	if err := convert(raw, v); err != nil {
+		return prefixLen, false, err
+	}
+	return prefixLen, true, nil
+}
+
+// find walks the search tree and returns the data record pointer, or 0 if there is none,
+// along with the prefix length at which the walk ended.
+func (r *Reader) find(ip net.IP) (uint, int, error) {
+	var (
+		addr []byte
+		node uint
+	)
+
+	if v4 := ip.To4(); v4 != nil {
+		addr = v4
+		if r.Metadata.IPVersion == 6 {
+			node = r.ipv4Start
+		}
+	} else if v6 := ip.To16(); v6 != nil {
+		if r.Metadata.IPVersion == 4 {
+			return 0, 0, fmt.Errorf("cannot look up IPv6 address %s in an IPv4-only database", ip)
+		}
+		addr = v6
+	} else {
+		return 0, 0, fmt.Errorf("invalid IP address: %v", ip)
+	}
+
+	nodeCount := r.Metadata.NodeCount
+	bits := len(addr) * 8
+	i := 0
+	for ; i < bits && node < nodeCount; i++ {
+		bit := (addr[i>>3] >> (7 - uint(i&7))) & 1
+		node = r.readNode(node, uint(bit))
+	}
+
+	switch {
+	case node == nodeCount:
+		return 0, i, nil
+	case node > nodeCount:
+		return node, i, nil
+	default:
+		return 0, 0, errors.New("invalid MaxMind DB: search tree is corrupt")
+	}
+}
+
+// readNode returns the left (bit 0) or right (bit 1) record of a node.
+func (r *Reader) readNode(node, bit uint) uint {
+	b := r.tree
+	switch r.Metadata.RecordSize {
+	case 24:
+		off := node*6 + bit*3
+		return uint(b[off])<<16 | uint(b[off+1])<<8 | uint(b[off+2])
+	case 28:
+		off := node * 7
+		if bit == 0 {
+			return uint(b[off+3]&0xf0)<<20 | uint(b[off])<<16 | uint(b[off+1])<<8 | uint(b[off+2])
+		}
+		return uint(b[off+3]&0x0f)<<24 | uint(b[off+4])<<16 | uint(b[off+5])<<8 | uint(b[off+6])
+	default:
+		off := node*8 + bit*4
+		return uint(b[off])<<24 | uint(b[off+1])<<16 | uint(b[off+2])<<8 | uint(b[off+3])
+	}
+}
+
+// convert copies a decoded value into v via its JSON representation.
+func convert(raw any, v any) error {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
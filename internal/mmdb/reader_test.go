@@ -0,0 +1,311 @@
+package mmdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"maps"
+	"net"
+	"net/netip"
+	"slices"
+	"testing"
+)
+
+// testDB is a database built in memory for tests, kept in its three sections
+// so tests can corrupt one of them.
+type testDB struct {
+	tree, data, meta []byte
+}
+
+// bytes joins the sections into a database file.
+func (db testDB) bytes() []byte {
+	return slices.Concat(db.tree, make([]byte, dataSectionSeparator), db.data, metadataMarker, db.meta)
+}
+
+// treeNode is a search tree node under construction.
+type treeNode struct {
+	records [2]treeRecord
+}
+
+// treeRecord points to another node, to data, or to nothing.
+type treeRecord struct {
+	node    *treeNode
+	data    int
+	hasData bool
+}
+
+// buildDB builds a database mapping each network to its record.
+// IPv4 networks are stored under ::/96 in IPv6 databases, as MaxMind does.
+func buildDB(t *testing.T, ipVersion, recordSize int, networks map[string]map[string]any) testDB {
+	t.Helper()
+
+	var db testDB
+	root := &treeNode{}
+	for _, network := range slices.Sorted(maps.Keys(networks)) {
+		prefix := netip.MustParsePrefix(network)
+		addr, bits := prefix.Addr().AsSlice(), prefix.Bits()
+		if ipVersion == 6 && prefix.Addr().Is4() {
+			addr, bits = append(make([]byte, 12), addr...), bits+96
+		}
+
+		node := root
+		for i := range bits - 1 {
+			rec := &node.records[bitAt(addr, i)]
+			if rec.node == nil {
+				rec.node = &treeNode{}
+			}
+			node = rec.node
+		}
+		node.records[bitAt(addr, bits-1)] = treeRecord{data: len(db.data), hasData: true}
+		db.data = append(db.data, encode(t, networks[network])...)
+	}
+
+	// Number the nodes breadth first, with the root as node 0.
+	nodes := []*treeNode{root}
+	index := map[*treeNode]uint{root: 0}
+	for i := 0; i < len(nodes); i++ {
+		for _, rec := range nodes[i].records {
+			if rec.node != nil {
+				index[rec.node] = uint(len(nodes))
+				nodes = append(nodes, rec.node)
+			}
+		}
+	}
+
+	nodeCount := uint(len(nodes))
+	for _, n := range nodes {
+		var values [2]uint
+		for i, rec := range n.records {
+			switch {
+			case rec.node != nil:
+				values[i] = index[rec.node]
+			case rec.hasData:
+				values[i] = nodeCount + dataSectionSeparator + uint(rec.data)
+			default:
+				values[i] = nodeCount
+			}
+		}
+		db.tree = append(db.tree, encodeNode(recordSize, values[0], values[1])...)
+	}
+
+	db.meta = encode(t, map[string]any{
+		"binary_format_major_version": uint16(2),
+		"build_epoch":                 uint64(1700000000),
+		"database_type":               "Test-Country",
+		"description":                 map[string]any{"en": "test database"},
+		"ip_version":                  uint16(ipVersion),
+		"languages":                   []any{"en"},
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(recordSize),
+	})
+	return db
+}
+
+// encodeNode encodes a node's left and right records at the given record size.
+func encodeNode(recordSize int, left, right uint) []byte {
+	switch recordSize {
+	case 24:
+		return []byte{byte(left >> 16), byte(left >> 8), byte(left), byte(right >> 16), byte(right >> 8), byte(right)}
+	case 28:
+		return []byte{
+			byte(left >> 16), byte(left >> 8), byte(left),
+			byte(left>>24)<<4 | byte(right>>24&0x0f),
+			byte(right >> 16), byte(right >> 8), byte(right),
+		}
+	default:
+		return binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(nil, uint32(left)), uint32(right))
+	}
+}
+
+// encode encodes a value in the data section format. Sizes must be below 29.
+func encode(t *testing.T, v any) []byte {
+	t.Helper()
+
+	ctrl := func(typ, size int) []byte {
+		if size >= 29 {
+			t.Fatalf("encode: size %d too large for a test fixture", size)
+		}
+		if typ > 7 {
+			return []byte{byte(size), byte(typ - 7)}
+		}
+		return []byte{byte(typ<<5 | size)}
+	}
+	unsignedField := func(typ int, v uint64, size int) []byte {
+		b := binary.BigEndian.AppendUint64(nil, v)[8-size:]
+		b = bytes.TrimLeft(b, "\x00")
+		return append(ctrl(typ, len(b)), b...)
+	}
+
+	switch v := v.(type) {
+	case string:
+		return append(ctrl(typeString, len(v)), v...)
+	case uint16:
+		return unsignedField(typeUint16, uint64(v), 2)
+	case uint32:
+		return unsignedField(typeUint32, uint64(v), 4)
+	case uint64:
+		return unsignedField(typeUint64, v, 8)
+	case bool:
+		if v {
+			return ctrl(typeBool, 1)
+		}
+		return ctrl(typeBool, 0)
+	case []any:
+		b := ctrl(typeArray, len(v))
+		for _, e := range v {
+			b = append(b, encode(t, e)...)
+		}
+		return b
+	case map[string]any:
+		b := ctrl(typeMap, len(v))
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			b = append(b, encode(t, k)...)
+			b = append(b, encode(t, v[k])...)
+		}
+		return b
+	default:
+		t.Fatalf("encode: unsupported type %T", v)
+		return nil
+	}
+}
+
+// bitAt returns bit i of addr, most significant first.
+func bitAt(addr []byte, i int) int {
+	return int(addr[i/8]>>(7-i%8)) & 1
+}
+
+// countryRecord is the record shape used by the test databases.
+type countryRecord struct {
+	Country struct {
+		ISOCode string `json:"iso_code"`
+	} `json:"country"`
+}
+
+func country(code string) map[string]any {
+	return map[string]any{"country": map[string]any{"iso_code": code}}
+}
+
+func TestLookup(t *testing.T) {
+	networks := map[string]map[string]any{
+		"1.2.3.0/24":    country("AU"),
+		"8.8.8.0/24":    country("US"),
+		"2001:db8::/32": country("ZZ"),
+	}
+
+	tests := []struct {
+		ip         string
+		ipVersion  int
+		wantCode   string
+		wantPrefix int
+		wantErr    bool
+	}{
+		{ip: "1.2.3.4", ipVersion: 4, wantCode: "AU", wantPrefix: 24},
+		{ip: "8.8.8.8", ipVersion: 4, wantCode: "US", wantPrefix: 24},
+		{ip: "9.9.9.9", ipVersion: 4},
+		{ip: "2001:db8::1", ipVersion: 4, wantErr: true},
+		{ip: "1.2.3.4", ipVersion: 6, wantCode: "AU", wantPrefix: 24},
+		{ip: "::ffff:1.2.3.4", ipVersion: 6, wantCode: "AU", wantPrefix: 24},
+		{ip: "::1.2.3.4", ipVersion: 6, wantCode: "AU", wantPrefix: 120},
+		{ip: "9.9.9.9", ipVersion: 6},
+		{ip: "2001:db8::1", ipVersion: 6, wantCode: "ZZ", wantPrefix: 32},
+		{ip: "2001:db9::1", ipVersion: 6},
+	}
+
+	for _, recordSize := range []int{24, 28, 32} {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%d/IPv%d/%s", recordSize, tt.ipVersion, tt.ip), func(t *testing.T) {
+				fixtures := networks
+				if tt.ipVersion == 4 {
+					fixtures = map[string]map[string]any{"1.2.3.0/24": networks["1.2.3.0/24"], "8.8.8.0/24": networks["8.8.8.0/24"]}
+				}
+				r, err := FromBytes(buildDB(t, tt.ipVersion, recordSize, fixtures).bytes())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if r.Metadata.RecordSize != uint(recordSize) || r.Metadata.IPVersion != uint(tt.ipVersion) {
+					t.Fatalf("metadata = %+v", r.Metadata)
+				}
+
+				var rec countryRecord
+				prefix, found, err := r.Lookup(net.ParseIP(tt.ip), &rec)
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+				}
+				if found != (tt.wantCode != "") || rec.Country.ISOCode != tt.wantCode {
+					t.Errorf("found %v %q, want %q", found, rec.Country.ISOCode, tt.wantCode)
+				}
+				if found && prefix != tt.wantPrefix {
+					t.Errorf("prefix length %d, want %d", prefix, tt.wantPrefix)
+				}
+			})
+		}
+	}
+}
+
+func TestReadNode(t *testing.T) {
+	tests := []struct {
+		recordSize  int
+		node        []byte
+		left, right uint
+	}{
+		{recordSize: 24, node: []byte{0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc}, left: 0x123456, right: 0x789abc},
+		{recordSize: 28, node: []byte{0xbc, 0xde, 0xf1, 0xa1, 0x23, 0x45, 0x67}, left: 0xabcdef1, right: 0x1234567},
+		{recordSize: 32, node: []byte{0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0}, left: 0x12345678, right: 0x9abcdef0},
+	}
+
+	for _, tt := range tests {
+		r := &Reader{Metadata: Metadata{RecordSize: uint(tt.recordSize)}, tree: tt.node}
+		if left, right := r.readNode(0, 0), r.readNode(0, 1); left != tt.left || right != tt.right {
+			t.Errorf("record size %d: got %#x %#x, want %#x %#x", tt.recordSize, left, right, tt.left, tt.right)
+		}
+		if got := encodeNode(tt.recordSize, tt.left, tt.right); !bytes.Equal(got, tt.node) {
+			t.Errorf("record size %d: encodeNode = %x, want %x", tt.recordSize, got, tt.node)
+		}
+	}
+}
+
+func TestFromBytesTruncated(t *testing.T) {
+	buf := buildDB(t, 6, 28, map[string]map[string]any{"1.2.3.0/24": country("AU")}).bytes()
+
+	for n := range len(buf) {
+		r, err := FromBytes(buf[:n])
+		if err == nil {
+			var rec countryRecord
+			_, _, err = r.Lookup(net.ParseIP("1.2.3.4"), &rec)
+		}
+		if err == nil {
+			t.Errorf("database truncated to %d of %d bytes was accepted", n, len(buf))
+		}
+	}
+}
+
+func TestLookupCorruptData(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(db *testDB)
+	}{
+		{name: "truncated data section", corrupt: func(db *testDB) { db.data = db.data[:len(db.data)-2] }},
+		{name: "record points into separator", corrupt: func(db *testDB) {
+			// Point both records of the last node into the separator.
+			nodeCount := uint(len(db.tree) / 6)
+			copy(db.tree[len(db.tree)-6:], encodeNode(24, nodeCount+1, nodeCount+1))
+		}},
+		{name: "looping pointer", corrupt: func(db *testDB) { db.data = []byte{0x20, 0x00} }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := buildDB(t, 4, 24, map[string]map[string]any{"1.2.3.0/24": country("AU")})
+			tt.corrupt(&db)
+			r, err := FromBytes(db.bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var rec countryRecord
+			if _, found, err := r.Lookup(net.ParseIP("1.2.3.4"), &rec); err == nil || found {
+				t.Errorf("got found %v, err %v; want an error", found, err)
+			}
+		})
+	}
+}
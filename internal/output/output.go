@@ -16,6 +16,7 @@ type Formatter interface {
 type Report struct {
 	IP        string             `json:"ip"`
 	Timestamp string             `json:"timestamp"`
+	Verdict   provider.Verdict   `json:"verdict,omitempty"`
 	Results   []*provider.Result `json:"results"`
 }
 
@@ -24,10 +25,50 @@ func NewReport(ip, timestamp string, results []*provider.Result) *Report {
 	return &Report{
 		IP:        ip,
 		Timestamp: timestamp,
+		Verdict:   provider.WorstVerdict(requested(results)),
 		Results:   results,
 	}
 }
 
+// Requested returns the number of results from requested providers.
+// Implicit results, from providers that only ran as dependencies, are not counted.
+func (r *Report) Requested() int {
+	return len(requested(r.Results))
+}
+
+// Failed returns the number of requested results that carry an error.
+func (r *Report) Failed() int {
+	failed := 0
+	for _, result := range requested(r.Results) {
+		if result.Error != "" {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Degraded returns the number of successful requested results that reported warnings.
+func (r *Report) Degraded() int {
+	degraded := 0
+	for _, result := range requested(r.Results) {
+		if result.Error == "" && len(result.Warnings) > 0 {
+			degraded++
+		}
+	}
+	return degraded
+}
+
+// requested returns the results that are not implicit.
+func requested(results []*provider.Result) []*provider.Result {
+	var out []*provider.Result
+	for _, r := range results {
+		if !r.Implicit {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
 // GetFormatter returns a formatter for the given format name.
 func GetFormatter(format string, w io.Writer) (Formatter, error) {
 	switch format {
@@ -0,0 +1,100 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// AuthScheme says how a provider sends its API key.
+type AuthScheme string
+
+const (
+	// AuthHeader sends the key in the header named by Auth.Name.
+	AuthHeader AuthScheme = "header"
+	// AuthQuery sends the key as the query parameter named by Auth.Name.
+	AuthQuery AuthScheme = "query"
+	// AuthBearer sends "Authorization: Bearer <key>".
+	AuthBearer AuthScheme = "bearer"
+	// AuthBasic sends HTTP basic auth with the key as username and Auth.Secret as password.
+	AuthBasic AuthScheme = "basic"
+)
+
+// ErrNoAPIKey is returned when a provider that requires a key runs without one.
+var ErrNoAPIKey = errors.New("API key not configured")
+
+// Auth describes how a provider authenticates. The zero value means no authentication.
+type Auth struct {
+	Scheme AuthScheme
+
+	// Name is the header or query parameter that carries the key.
+	Name string
+
+	Key    string
+	Secret string
+}
+
+// Required reports whether the provider needs a key.
+func (a *Auth) Required() bool {
+	return a.Scheme != ""
+}
+
+// Apply adds the credentials to req.
+func (a *Auth) Apply(req *http.Request) error {
+	if !a.Required() {
+		return nil
+	}
+	if a.Key == "" {
+		return NewError(ErrorKindAuth, ErrNoAPIKey)
+	}
+
+	switch a.Scheme {
+	case AuthHeader:
+		req.Header.Set(a.Name, a.Key)
+	case AuthQuery:
+		q := req.URL.Query()
+		q.Set(a.Name, a.Key)
+		req.URL.RawQuery = q.Encode()
+	case AuthBearer:
+		req.Header.Set("Authorization", "Bearer "+a.Key)
+	case AuthBasic:
+		req.SetBasicAuth(a.Key, a.Secret)
+	default:
+		return fmt.Errorf("unknown auth scheme %q", a.Scheme)
+	}
+	return nil
+}
+
+// KeySettings are the config file settings shared by providers that use an API key.
+type KeySettings struct {
+	// APIKey is the key itself.
+	APIKey string `json:"api_key"`
+
+	// APIKeyEnv names an environment variable holding the key, to keep it out of the file.
+	APIKeyEnv string `json:"api_key_env"`
+
+	// APISecret and APISecretEnv are the password half of basic-auth credentials.
+	APISecret    string `json:"api_secret"`
+	APISecretEnv string `json:"api_secret_env"`
+}
+
+// Apply copies the configured credentials into auth. A key whose environment
+// variable is unset is left empty, so the provider stays disabled.
+func (s KeySettings) Apply(auth *Auth) {
+	auth.Key = s.Key()
+	auth.Secret = lookupSecret(s.APISecret, s.APISecretEnv)
+}
+
+// Key returns the configured API key, if any.
+func (s KeySettings) Key() string {
+	return lookupSecret(s.APIKey, s.APIKeyEnv)
+}
+
+// lookupSecret returns value, or the environment variable env if value is empty.
+func lookupSecret(value, env string) string {
+	if value != "" || env == "" {
+		return value
+	}
+	return os.Getenv(env)
+}
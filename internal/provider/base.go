@@ -2,11 +2,18 @@ package provider
 
 import (
 	"context"
-	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"strings"
 )
 
+// Request body content types for BaseProvider.ContentType.
+const (
+	ContentTypeJSON = "application/json"
+	ContentTypeForm = "application/x-www-form-urlencoded"
+)
+
 // BaseProvider provides common functionality for providers.
 // Embed this in your provider implementation to get sensible defaults.
 type BaseProvider struct {
@@ -15,6 +22,19 @@ type BaseProvider struct {
 	URLTemplate  string
 	Headers      map[string]string
 	Method       string
+
+	// BodyTemplate is the request body, with {ip} substituted. Setting it makes
+	// the default method POST. The IP is URL-encoded for form bodies.
+	BodyTemplate string
+
+	// ContentType is sent with BodyTemplate (default: application/json).
+	ContentType string
+
+	// Auth is how the provider authenticates; the zero value means it doesn't.
+	Auth Auth
+
+	// MaxBody overrides MaxBodySize for this provider when non-zero.
+	MaxBody int64
 }
 
 // Name returns the provider's display name.
@@ -27,29 +47,61 @@ func (b *BaseProvider) ID() string {
 	return b.ProviderID
 }
 
-// BuildRequest creates a basic HTTP request with the IP substituted into the URL template.
-// Override this method if you need custom request building (POST body, auth, etc.).
+// BodyLimit returns the provider's response body limit, or zero to use MaxBodySize.
+func (b *BaseProvider) BodyLimit() int64 {
+	return b.MaxBody
+}
+
+// Enabled reports whether the provider has the API key it needs, if any.
+func (b *BaseProvider) Enabled() bool {
+	return !b.Auth.Required() || b.Auth.Key != ""
+}
+
+// BuildRequest creates an HTTP request with the IP substituted into the URL and body templates
+// and the provider's credentials applied.
+// Override this method if you need custom request building.
 func (b *BaseProvider) BuildRequest(ctx context.Context, ip string) (*http.Request, error) {
-	url := strings.ReplaceAll(b.URLTemplate, "{ip}", ip)
+	endpoint := strings.ReplaceAll(b.URLTemplate, "{ip}", ip)
 
 	method := b.Method
+	var body io.Reader
+	contentType := b.ContentType
+	if b.BodyTemplate != "" {
+		if contentType == "" {
+			contentType = ContentTypeJSON
+		}
+
+		value := ip
+		if contentType == ContentTypeForm {
+			value = url.QueryEscape(ip)
+		}
+		body = strings.NewReader(strings.ReplaceAll(b.BodyTemplate, "{ip}", value))
+
+		if method == "" {
+			method = http.MethodPost
+		}
+	}
 	if method == "" {
 		method = http.MethodGet
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	req, err := NewRequest(ctx, method, endpoint, body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
-	req.Header.Set("Accept", "application/json")
-	// TODO: make this use the actual version not just a hardcoded string
-	req.Header.Set("User-Agent", "dalryan/ip-enrich")
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
 
 	for k, v := range b.Headers {
 		req.Header.Set(k, v)
 	}
 
+	if err := b.Auth.Apply(req); err != nil {
+		return nil, err
+	}
+
 	return req, nil
 }
 
@@ -60,6 +112,8 @@ func NewErrorResult(p Provider, statusCode int, err error) *Result {
 		ProviderName: p.Name(),
 		StatusCode:   statusCode,
 		Error:        err.Error(),
+		ErrorKind:    KindOf(err),
+		Err:          err,
 	}
 }
 
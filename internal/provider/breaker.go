@@ -0,0 +1,179 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is reported when a provider is skipped because its circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit open")
+
+// CircuitState describes the state of a provider's circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets every request through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen short-circuits every request until the cool-down elapses.
+	CircuitOpen
+	// CircuitHalfOpen lets a single probe request through to test recovery.
+	CircuitHalfOpen
+)
+
+// String returns the lowercase name of the state.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText encodes the state as its name.
+func (s CircuitState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a state name.
+func (s *CircuitState) UnmarshalText(text []byte) error {
+	for _, state := range []CircuitState{CircuitClosed, CircuitOpen, CircuitHalfOpen} {
+		if string(text) == state.String() {
+			*s = state
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown circuit state %q", text)
+}
+
+// BreakerState is a snapshot of one provider's circuit breaker.
+// The CLI saves it between runs so that repeated failures open the breaker
+// even though each invocation builds a new Executor.
+type BreakerState struct {
+	State    CircuitState `json:"state"`
+	Failures int          `json:"failures"`
+	OpenedAt time.Time    `json:"opened_at"`
+}
+
+// circuitBreaker tracks consecutive failures for a single provider.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	state     CircuitState
+	openedAt  time.Time
+	probing   bool
+}
+
+// newCircuitBreaker creates a closed breaker that opens after threshold consecutive failures.
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// allow reports whether a request may proceed.
+// An open breaker moves to half-open once the cool-down has elapsed and lets one probe through.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = CircuitHalfOpen
+		b.probing = true
+		return true
+	case CircuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record updates the breaker with the outcome of a request that was allowed through.
+func (b *circuitBreaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+
+	if !failed {
+		b.failures = 0
+		b.state = CircuitClosed
+		return
+	}
+
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.threshold {
+		b.state = CircuitOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// release gives up an in-flight probe without recording an outcome (e.g. on cancellation).
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// snapshot returns the breaker's state for saving.
+func (b *circuitBreaker) snapshot() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return BreakerState{State: b.state, Failures: b.failures, OpenedAt: b.openedAt}
+}
+
+// restore loads a saved state. A breaker saved mid-probe is restored as open,
+// so it lets a new probe through once the cool-down has elapsed.
+func (b *circuitBreaker) restore(s BreakerState) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = s.State
+	if b.state == CircuitHalfOpen {
+		b.state = CircuitOpen
+	}
+	b.failures = s.Failures
+	b.openedAt = s.OpenedAt
+	b.probing = false
+}
+
+// countsAsFailure reports whether a result means the provider itself is unhealthy.
+// Only transport failures, timeouts, throttling and 5xx responses count; answers
+// such as not_found, auth or parse errors show the provider is up and close the breaker.
+func countsAsFailure(result *Result) bool {
+	switch KindOf(result.Err) {
+	case ErrorKindTimeout, ErrorKindNetwork, ErrorKindRateLimited:
+		return true
+	case ErrorKindHTTPStatus:
+		return statusCodeOf(result.Err) >= 500 || result.StatusCode >= 500
+	default:
+		return false
+	}
+}
+
+// current returns the breaker's state.
+func (b *circuitBreaker) current() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
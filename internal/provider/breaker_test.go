@@ -0,0 +1,161 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	const cooldown = 20 * time.Millisecond
+	b := newCircuitBreaker(2, cooldown)
+
+	steps := []struct {
+		name    string
+		failed  bool
+		allowed bool
+		want    CircuitState
+	}{
+		{name: "first failure", failed: true, allowed: true, want: CircuitClosed},
+		{name: "threshold reached", failed: true, allowed: true, want: CircuitOpen},
+	}
+	for _, s := range steps {
+		if got := b.allow(); got != s.allowed {
+			t.Fatalf("%s: allow() = %v, want %v", s.name, got, s.allowed)
+		}
+		b.record(s.failed)
+		if got := b.current(); got != s.want {
+			t.Fatalf("%s: state = %v, want %v", s.name, got, s.want)
+		}
+	}
+
+	if b.allow() {
+		t.Fatal("open breaker allowed a request during the cool-down")
+	}
+
+	time.Sleep(cooldown)
+	if !b.allow() {
+		t.Fatal("breaker did not let a probe through after the cool-down")
+	}
+	if got := b.current(); got != CircuitHalfOpen {
+		t.Fatalf("state after cool-down = %v, want half-open", got)
+	}
+	if b.allow() {
+		t.Fatal("half-open breaker allowed a second concurrent probe")
+	}
+
+	// A failed probe reopens the breaker immediately.
+	b.record(true)
+	if got := b.current(); got != CircuitOpen {
+		t.Fatalf("state after failed probe = %v, want open", got)
+	}
+
+	time.Sleep(cooldown)
+	if !b.allow() {
+		t.Fatal("breaker did not let a second probe through")
+	}
+	b.record(false)
+	if got := b.current(); got != CircuitClosed {
+		t.Fatalf("state after successful probe = %v, want closed", got)
+	}
+	if !b.allow() {
+		t.Fatal("closed breaker rejected a request")
+	}
+}
+
+func TestCircuitBreakerRelease(t *testing.T) {
+	b := newCircuitBreaker(1, 0)
+	b.allow()
+	b.record(true)
+
+	if !b.allow() {
+		t.Fatal("probe not allowed after zero cool-down")
+	}
+	b.release()
+	if !b.allow() {
+		t.Fatal("released probe did not free the half-open slot")
+	}
+}
+
+func TestBreakerStateRoundTrip(t *testing.T) {
+	openedAt := time.Now().Add(-time.Second).UTC().Truncate(time.Second)
+	saved := map[string]BreakerState{
+		"flaky": {State: CircuitOpen, Failures: 5, OpenedAt: openedAt},
+		"probe": {State: CircuitHalfOpen, Failures: 5, OpenedAt: openedAt},
+	}
+
+	data, err := json.Marshal(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded map[string]BreakerState
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatal(err)
+	}
+
+	e := NewExecutor(WithCircuitBreaker(5, time.Hour), WithBreakerStates(loaded))
+	if got := e.CircuitState("flaky"); got != CircuitOpen {
+		t.Errorf("flaky restored as %v, want open", got)
+	}
+	// A breaker saved mid-probe comes back open rather than with a probe in flight.
+	if got := e.CircuitState("probe"); got != CircuitOpen {
+		t.Errorf("probe restored as %v, want open", got)
+	}
+
+	results := e.Execute(context.Background(), "192.0.2.1", []Provider{stubProvider{id: "flaky"}}, nil)
+	if !errors.Is(results[0].Err, ErrCircuitOpen) {
+		t.Errorf("restored open breaker ran the provider: %+v", results[0])
+	}
+
+	states := e.BreakerStates()
+	if got := states["flaky"]; got.State != CircuitOpen || got.Failures != 5 || !got.OpenedAt.Equal(openedAt) {
+		t.Errorf("BreakerStates()[flaky] = %+v", got)
+	}
+}
+
+func TestBreakerStatesIgnoredWhenDisabled(t *testing.T) {
+	e := NewExecutor(WithCircuitBreaker(0, 0), WithBreakerStates(map[string]BreakerState{
+		"flaky": {State: CircuitOpen, Failures: 5, OpenedAt: time.Now()},
+	}))
+
+	results := e.Execute(context.Background(), "192.0.2.1", []Provider{stubProvider{id: "flaky"}}, nil)
+	if results[0].Error != "" {
+		t.Errorf("disabled breaker short-circuited the provider: %s", results[0].Error)
+	}
+}
+
+func TestBreakerCountsOnlyProviderFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantOpen bool
+	}{
+		{name: "not found", err: NewStatusError(404)},
+		{name: "auth", err: NewStatusError(401)},
+		{name: "parse", err: NewParseError(errors.New("bad json"))},
+		{name: "bad request", err: NewStatusError(400)},
+		{name: "server error", err: NewStatusError(503), wantOpen: true},
+		{name: "rate limited", err: NewStatusError(429), wantOpen: true},
+		{name: "network", err: &net.OpError{Op: "dial", Err: errors.New("refused")}, wantOpen: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewExecutor(WithCircuitBreaker(1, time.Hour))
+			p := stubProvider{id: "p", lookup: func(context.Context, string) (*Result, error) {
+				return nil, tt.err
+			}}
+
+			results := e.Execute(context.Background(), "192.0.2.1", []Provider{p}, nil)
+			if results[0].Error == "" {
+				t.Fatal("expected the lookup to fail")
+			}
+			if got, want := e.CircuitState("p"), CircuitClosed; tt.wantOpen != (got != want) {
+				t.Errorf("state = %v, want open %v", got, tt.wantOpen)
+			}
+		})
+	}
+}
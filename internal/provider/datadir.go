@@ -0,0 +1,36 @@
+package provider
+
+import (
+	"path/filepath"
+	"sync"
+)
+
+var (
+	dataDirMu sync.RWMutex
+	dataDir   string
+)
+
+// SetDataDir sets the directory that relative local-data paths are resolved against.
+// It must be called before Configure for the paths to take effect.
+func SetDataDir(dir string) {
+	dataDirMu.Lock()
+	defer dataDirMu.Unlock()
+	dataDir = dir
+}
+
+// DataDir returns the directory set by SetDataDir.
+func DataDir() string {
+	dataDirMu.RLock()
+	defer dataDirMu.RUnlock()
+	return dataDir
+}
+
+// DataPath resolves a local-data path from provider settings.
+// Absolute paths are returned unchanged; relative paths are joined to the data directory.
+func DataPath(path string) string {
+	dir := DataDir()
+	if path == "" || filepath.IsAbs(path) || dir == "" {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
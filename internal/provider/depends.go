@@ -0,0 +1,121 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrDependencyCycle is reported for providers whose dependencies lead back to themselves.
+	ErrDependencyCycle = errors.New("provider dependency cycle")
+	// ErrDependencyFailed is reported for providers whose dependencies in the run all failed.
+	ErrDependencyFailed = errors.New("provider dependencies failed")
+)
+
+// upstreamKey is the context key for a dependent provider's upstream results.
+type upstreamKey struct{}
+
+// Upstream returns the result a dependency produced earlier in the same run.
+// It reports false if id is not one of the calling provider's dependencies
+// or did not take part in the run.
+func Upstream(ctx context.Context, id string) (*Result, bool) {
+	results, _ := ctx.Value(upstreamKey{}).(map[string]*Result)
+	r, ok := results[id]
+	return r, ok
+}
+
+// dependencies returns the IDs p depends on, or nil if it is not a Dependent.
+func dependencies(p Provider) []string {
+	if d, ok := p.(Dependent); ok {
+		return d.DependsOn()
+	}
+	return nil
+}
+
+// pending is a provider's result slot within one Execute call.
+// result is written once, before done is closed.
+type pending struct {
+	done   chan struct{}
+	result *Result
+}
+
+// awaitDependencies waits for p's dependencies in this run to finish and
+// returns ctx carrying their results. Dependencies outside the run are skipped.
+// It returns an ErrDependencyFailed error if every dependency in the run failed.
+func awaitDependencies(ctx context.Context, p Provider, slots map[string]*pending) (context.Context, error) {
+	deps := dependencies(p)
+	if len(deps) == 0 {
+		return ctx, nil
+	}
+
+	results := make(map[string]*Result, len(deps))
+	var failed []string
+	for _, id := range deps {
+		slot, ok := slots[id]
+		if !ok {
+			continue
+		}
+		<-slot.done
+		results[id] = slot.result
+		if slot.result.Error != "" {
+			failed = append(failed, id)
+		}
+	}
+
+	if len(results) > 0 && len(failed) == len(results) {
+		return ctx, fmt.Errorf("%w: %s", ErrDependencyFailed, strings.Join(failed, ", "))
+	}
+	return context.WithValue(ctx, upstreamKey{}, results), nil
+}
+
+// dependencyCycles returns the IDs of providers on dependency cycles among providers,
+// found as back edges of a depth-first search. Every cycle has at least one member
+// in the result, so failing those providers up front is enough to avoid a deadlock.
+func dependencyCycles(providers []Provider) map[string]bool {
+	byID := make(map[string]Provider, len(providers))
+	for _, p := range providers {
+		byID[p.ID()] = p
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(providers))
+	cyclic := make(map[string]bool)
+	var stack []string
+
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = visiting
+		stack = append(stack, id)
+		for _, dep := range dependencies(byID[id]) {
+			if _, ok := byID[dep]; !ok {
+				continue
+			}
+			switch state[dep] {
+			case 0:
+				visit(dep)
+			case visiting:
+				// Everything on the stack from dep onwards is in the cycle.
+				for i := len(stack) - 1; i >= 0; i-- {
+					cyclic[stack[i]] = true
+					if stack[i] == dep {
+						break
+					}
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[id] = visited
+	}
+
+	for _, p := range providers {
+		if state[p.ID()] == 0 {
+			visit(p.ID())
+		}
+	}
+	return cyclic
+}
@@ -0,0 +1,160 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// executeByID runs providers and returns their results keyed by provider ID,
+// failing the test if the run does not finish.
+func executeByID(t *testing.T, providers ...Provider) map[string]*Result {
+	t.Helper()
+
+	done := make(chan []*Result, 1)
+	go func() {
+		done <- NewExecutor(WithCircuitBreaker(0, 0)).Execute(context.Background(), "192.0.2.1", providers, nil)
+	}()
+
+	select {
+	case results := <-done:
+		byID := make(map[string]*Result, len(results))
+		for _, r := range results {
+			byID[r.ProviderID] = r
+		}
+		if len(byID) != len(providers) {
+			t.Fatalf("got %d results, want %d", len(byID), len(providers))
+		}
+		return byID
+	case <-time.After(5 * time.Second):
+		t.Fatal("Execute did not finish, dependencies deadlocked")
+		return nil
+	}
+}
+
+// failing returns a lookup func that fails with err.
+func failing(err error) func(context.Context, string) (*Result, error) {
+	return func(context.Context, string) (*Result, error) { return nil, err }
+}
+
+func TestExecuteDependencyOrder(t *testing.T) {
+	var upstreamDone atomic.Bool
+	up := stubProvider{id: "up", lookup: func(context.Context, string) (*Result, error) {
+		time.Sleep(50 * time.Millisecond)
+		upstreamDone.Store(true)
+		return NewSuccessResult(stubProvider{id: "up"}, 0, "asn"), nil
+	}}
+
+	var sawDone bool
+	var upstream *Result
+	down := stubProvider{id: "down", deps: []string{"up"}}
+	down.lookup = func(ctx context.Context, _ string) (*Result, error) {
+		sawDone = upstreamDone.Load()
+		upstream, _ = Upstream(ctx, "up")
+		return NewSuccessResult(down, 0, nil), nil
+	}
+
+	results := executeByID(t, down, up)
+
+	if !sawDone {
+		t.Error("dependent started before its upstream finished")
+	}
+	if upstream == nil || upstream.Raw != "asn" {
+		t.Errorf("Upstream = %+v, want the upstream result", upstream)
+	}
+	if r := results["down"]; r.Error != "" {
+		t.Errorf("dependent failed: %s", r.Error)
+	}
+}
+
+func TestExecuteDependencyCycles(t *testing.T) {
+	var ran atomic.Int32
+	counting := func(id string, deps ...string) stubProvider {
+		s := stubProvider{id: id, deps: deps}
+		s.lookup = func(context.Context, string) (*Result, error) {
+			ran.Add(1)
+			return NewSuccessResult(s, 0, nil), nil
+		}
+		return s
+	}
+
+	results := executeByID(t,
+		counting("a", "b"),
+		counting("b", "a"),
+		counting("self", "self"),
+		counting("after", "a"),
+		counting("free"),
+	)
+
+	for _, id := range []string{"a", "b", "self", "after"} {
+		if r := results[id]; r.ErrorKind != ErrorKindDependency {
+			t.Errorf("%s: error kind %q (%s), want %q", id, r.ErrorKind, r.Error, ErrorKindDependency)
+		}
+	}
+	if !errors.Is(results["self"].Err, ErrDependencyCycle) {
+		t.Errorf("self: err = %v, want %v", results["self"].Err, ErrDependencyCycle)
+	}
+	if r := results["free"]; r.Error != "" {
+		t.Errorf("free: unexpected error %s", r.Error)
+	}
+	if n := ran.Load(); n != 1 {
+		t.Errorf("%d providers ran, want only the one outside the cycle", n)
+	}
+}
+
+func TestExecuteMissingDependency(t *testing.T) {
+	var found bool
+	down := stubProvider{id: "down", deps: []string{"absent"}}
+	down.lookup = func(ctx context.Context, _ string) (*Result, error) {
+		_, found = Upstream(ctx, "absent")
+		return NewSuccessResult(down, 0, nil), nil
+	}
+
+	results := executeByID(t, down)
+
+	if r := results["down"]; r.Error != "" {
+		t.Errorf("dependent failed: %s", r.Error)
+	}
+	if found {
+		t.Error("Upstream reported a dependency that was not in the run")
+	}
+}
+
+func TestExecuteFailedDependencies(t *testing.T) {
+	errUpstream := NewError(ErrorKindNetwork, errors.New("unreachable"))
+
+	tests := []struct {
+		name    string
+		second  stubProvider
+		wantRun bool
+	}{
+		{name: "all failed", second: stubProvider{id: "second", lookup: failing(errUpstream)}},
+		{name: "one succeeded", second: stubProvider{id: "second"}, wantRun: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ran bool
+			down := stubProvider{id: "down", deps: []string{"first", "second", "absent"}}
+			down.lookup = func(context.Context, string) (*Result, error) {
+				ran = true
+				return NewSuccessResult(down, 0, nil), nil
+			}
+
+			results := executeByID(t, down, stubProvider{id: "first", lookup: failing(errUpstream)}, tt.second)
+
+			if ran != tt.wantRun {
+				t.Errorf("dependent ran = %v, want %v", ran, tt.wantRun)
+			}
+			r := results["down"]
+			if tt.wantRun && r.Error != "" {
+				t.Errorf("dependent failed: %s", r.Error)
+			}
+			if !tt.wantRun && (r.ErrorKind != ErrorKindDependency || !errors.Is(r.Err, ErrDependencyFailed)) {
+				t.Errorf("got kind %q, err %v; want %q", r.ErrorKind, r.Err, ErrorKindDependency)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+)
+
+// ErrorKind is a machine-readable classification of a provider failure.
+type ErrorKind string
+
+const (
+	// ErrorKindTimeout means the request did not finish before its deadline.
+	ErrorKindTimeout ErrorKind = "timeout"
+	// ErrorKindCancelled means the run was cancelled (e.g. Ctrl-C).
+	ErrorKindCancelled ErrorKind = "cancelled"
+	// ErrorKindNetwork means the request failed below HTTP (DNS, connect, TLS, reset).
+	ErrorKindNetwork ErrorKind = "network"
+	// ErrorKindHTTPStatus means the provider answered with an unexpected status code.
+	ErrorKindHTTPStatus ErrorKind = "http_status"
+	// ErrorKindRateLimited means the provider throttled the request.
+	ErrorKindRateLimited ErrorKind = "rate_limited"
+	// ErrorKindAuth means credentials were missing or rejected.
+	ErrorKindAuth ErrorKind = "auth"
+	// ErrorKindParse means the response could not be decoded.
+	ErrorKindParse ErrorKind = "parse"
+	// ErrorKindTooLarge means the response body exceeded the provider's body limit.
+	ErrorKindTooLarge ErrorKind = "too_large"
+	// ErrorKindAPI means the provider answered successfully but reported an error in the body.
+	ErrorKindAPI ErrorKind = "api"
+	// ErrorKindNotFound means the provider does not know the IP and treats that as an error.
+	ErrorKindNotFound ErrorKind = "not_found"
+	// ErrorKindQuota means the account's usage quota is exhausted.
+	ErrorKindQuota ErrorKind = "quota"
+	// ErrorKindCircuitOpen means the provider was skipped by its circuit breaker.
+	ErrorKindCircuitOpen ErrorKind = "circuit_open"
+	// ErrorKindFeedMissing means a local data file the provider reads has not been downloaded.
+	ErrorKindFeedMissing ErrorKind = "feed_missing"
+	// ErrorKindDependency means every provider this one depends on failed, or the dependencies form a cycle.
+	ErrorKindDependency ErrorKind = "dependency"
+	// ErrorKindUnknown is used for errors that do not fit any other kind.
+	ErrorKindUnknown ErrorKind = "unknown"
+)
+
+// Error is a classified provider failure.
+// Use errors.As to recover it from Result.Err.
+type Error struct {
+	Kind       ErrorKind
+	StatusCode int
+	Err        error
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// NewError wraps err with the given kind.
+func NewError(kind ErrorKind, err error) *Error {
+	return &Error{Kind: kind, Err: err}
+}
+
+// NewStatusError classifies an unexpected HTTP status code.
+func NewStatusError(statusCode int) *Error {
+	kind := ErrorKindHTTPStatus
+	switch statusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		kind = ErrorKindAuth
+	case http.StatusNotFound:
+		kind = ErrorKindNotFound
+	case http.StatusTooManyRequests:
+		kind = ErrorKindRateLimited
+	}
+
+	return &Error{
+		Kind:       kind,
+		StatusCode: statusCode,
+		Err:        fmt.Errorf("unexpected status code: %d", statusCode),
+	}
+}
+
+// NewParseError wraps a response decoding failure.
+func NewParseError(err error) *Error {
+	return NewError(ErrorKindParse, fmt.Errorf("failed to parse response: %w", err))
+}
+
+// statusCodeOf returns the HTTP status code recorded on a classified error, or zero.
+func statusCodeOf(err error) int {
+	var pe *Error
+	if errors.As(err, &pe) {
+		return pe.StatusCode
+	}
+	return 0
+}
+
+// KindOf returns the ErrorKind for err.
+// Errors that were not classified by a provider are inferred where possible.
+func KindOf(err error) ErrorKind {
+	if err == nil {
+		return ""
+	}
+
+	var pe *Error
+	if errors.As(err, &pe) {
+		return pe.Kind
+	}
+
+	var tooLarge *BodyTooLargeError
+	if errors.As(err, &tooLarge) {
+		return ErrorKindTooLarge
+	}
+
+	if errors.Is(err, ErrCircuitOpen) {
+		return ErrorKindCircuitOpen
+	}
+
+	if errors.Is(err, ErrDependencyCycle) || errors.Is(err, ErrDependencyFailed) {
+		return ErrorKindDependency
+	}
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrorKindFeedMissing
+	}
+
+	// Local file errors wrap a syscall.Errno, which also satisfies net.Error.
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return ErrorKindUnknown
+	}
+
+	var ne net.Error
+	if errors.As(err, &ne) {
+		if ne.Timeout() {
+			return ErrorKindTimeout
+		}
+		return ErrorKindNetwork
+	}
+
+	return ErrorKindUnknown
+}
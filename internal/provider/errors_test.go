@@ -0,0 +1,55 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"syscall"
+	"testing"
+)
+
+func TestKindOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorKind
+	}{
+		{name: "nil", err: nil, want: ""},
+		{name: "classified", err: NewError(ErrorKindQuota, errors.New("quota")), want: ErrorKindQuota},
+		{name: "wrapped classified", err: fmt.Errorf("lookup: %w", NewStatusError(429)), want: ErrorKindRateLimited},
+		{name: "status 401", err: NewStatusError(401), want: ErrorKindAuth},
+		{name: "status 404", err: NewStatusError(404), want: ErrorKindNotFound},
+		{name: "status 500", err: NewStatusError(500), want: ErrorKindHTTPStatus},
+		{name: "parse", err: NewParseError(errors.New("bad json")), want: ErrorKindParse},
+		{name: "body too large", err: &BodyTooLargeError{Limit: 10}, want: ErrorKindTooLarge},
+		{name: "circuit open", err: ErrCircuitOpen, want: ErrorKindCircuitOpen},
+		{name: "dependency cycle", err: ErrDependencyCycle, want: ErrorKindDependency},
+		{name: "dependencies failed", err: fmt.Errorf("%w: a, b", ErrDependencyFailed), want: ErrorKindDependency},
+		{name: "network", err: &net.OpError{Op: "dial", Err: errors.New("refused")}, want: ErrorKindNetwork},
+		{name: "timeout", err: &net.DNSError{IsTimeout: true}, want: ErrorKindTimeout},
+		{name: "missing local file", err: fmt.Errorf("load: %w", &fs.PathError{Op: "open", Path: "x", Err: syscall.ENOENT}), want: ErrorKindFeedMissing},
+		{name: "unreadable local file", err: &fs.PathError{Op: "open", Path: "x", Err: syscall.EACCES}, want: ErrorKindUnknown},
+		{name: "unclassified", err: errors.New("boom"), want: ErrorKindUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := KindOf(tt.err); got != tt.want {
+				t.Errorf("KindOf(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContextError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if got := KindOf(contextError(ctx)); got != ErrorKindCancelled {
+		t.Errorf("cancelled context: kind %q, want %q", got, ErrorKindCancelled)
+	}
+	if err := contextError(context.Background()); err != nil {
+		t.Errorf("live context: got %v, want nil", err)
+	}
+}
@@ -4,20 +4,59 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"net/http/httptrace"
 	"sync"
 	"time"
 )
 
-// MaxBodySize defines the maximum bytes we will read from any provider (5MB).
+// MaxBodySize defines the default maximum bytes we will read from any provider (5MB).
 // This just a light safeguard against memory exhaustion.
+// Providers can raise or lower it by implementing BodyLimiter.
 const MaxBodySize = 5 * 1024 * 1024
 
+// BodyLimiter is implemented by providers that need a response body limit other than MaxBodySize.
+type BodyLimiter interface {
+	// BodyLimit returns the maximum number of body bytes to read, or zero for the default.
+	BodyLimit() int64
+}
+
+// BodyTooLargeError is returned when a response body exceeds the provider's body limit.
+type BodyTooLargeError struct {
+	Limit int64
+}
+
+// Error implements the error interface.
+func (e *BodyTooLargeError) Error() string {
+	return fmt.Sprintf("response body truncated: exceeded %d byte limit", e.Limit)
+}
+
+// isBodyTooLarge reports whether err is or wraps a *BodyTooLargeError.
+func isBodyTooLarge(err error) bool {
+	var tooLarge *BodyTooLargeError
+	return errors.As(err, &tooLarge)
+}
+
+// errNoResult is reported when a provider returns neither a result nor an error.
+var errNoResult = errors.New("provider returned no result")
+
+// Default circuit breaker settings.
+const (
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 30 * time.Second
+)
+
 // Executor runs providers concurrently and collects results.
 type Executor struct {
-	client  *http.Client
-	timeout time.Duration
+	client          *http.Client
+	providerClients map[string]*http.Client
+	timeout         time.Duration
+
+	breakerThreshold int
+	breakerCooldown  time.Duration
+	breakersMu       sync.Mutex
+	breakers         map[string]*circuitBreaker
+	savedBreakers    map[string]BreakerState
 }
 
 // ExecutorOption configures an Executor.
@@ -37,16 +76,55 @@ func WithHTTPClient(c *http.Client) ExecutorOption {
 	}
 }
 
+// WithProviderHTTPClient sets the HTTP client used for a single provider,
+// e.g. to route it through a different proxy.
+func WithProviderHTTPClient(id string, c *http.Client) ExecutorOption {
+	return func(e *Executor) {
+		if e.providerClients == nil {
+			e.providerClients = make(map[string]*http.Client)
+		}
+		e.providerClients[id] = c
+	}
+}
+
+// WithCircuitBreaker configures the per-provider circuit breaker.
+// The breaker opens after threshold consecutive failures and half-opens after cooldown.
+// A threshold of zero or less disables the breaker.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) ExecutorOption {
+	return func(e *Executor) {
+		e.breakerThreshold = threshold
+		e.breakerCooldown = cooldown
+	}
+}
+
+// WithBreakerStates restores circuit breakers saved by BreakerStates,
+// e.g. by an earlier invocation of the CLI. It has no effect if breakers are disabled.
+func WithBreakerStates(states map[string]BreakerState) ExecutorOption {
+	return func(e *Executor) {
+		e.savedBreakers = states
+	}
+}
+
 // NewExecutor creates a new provider executor.
 func NewExecutor(opts ...ExecutorOption) *Executor {
 	e := &Executor{
-		timeout: 10 * time.Second,
+		timeout:          10 * time.Second,
+		breakerThreshold: DefaultBreakerThreshold,
+		breakerCooldown:  DefaultBreakerCooldown,
+		breakers:         make(map[string]*circuitBreaker),
 	}
 
 	for _, opt := range opts {
 		opt(e)
 	}
 
+	// Restore after all options are applied, so the threshold and cool-down are final.
+	for id, state := range e.savedBreakers {
+		if b := e.breaker(id); b != nil {
+			b.restore(state)
+		}
+	}
+
 	if e.client == nil {
 		e.client = &http.Client{
 			Timeout: e.timeout,
@@ -60,6 +138,8 @@ func NewExecutor(opts ...ExecutorOption) *Executor {
 type ResultCallback func(result *Result)
 
 // Execute runs all providers concurrently for the given IP.
+// A Dependent provider starts once its dependencies in providers have finished,
+// and fails without running if they all failed.
 // The callback is called for each result as it completes.
 // Returns all results when complete.
 func (e *Executor) Execute(ctx context.Context, ip string, providers []Provider, callback ResultCallback) []*Result {
@@ -69,12 +149,29 @@ func (e *Executor) Execute(ctx context.Context, ip string, providers []Provider,
 		results []*Result
 	)
 
+	slots := make(map[string]*pending, len(providers))
+	for _, p := range providers {
+		slots[p.ID()] = &pending{done: make(chan struct{})}
+	}
+	cyclic := dependencyCycles(providers)
+
 	for _, p := range providers {
 		wg.Add(1)
 		go func(p Provider) {
 			defer wg.Done()
 
-			result := e.executeOne(ctx, ip, p)
+			var result *Result
+			if cyclic[p.ID()] {
+				result = NewErrorResult(p, 0, ErrDependencyCycle)
+			} else if depCtx, err := awaitDependencies(ctx, p, slots); err != nil {
+				result = NewErrorResult(p, 0, err)
+			} else {
+				result = e.executeGuarded(depCtx, ip, p)
+			}
+
+			slot := slots[p.ID()]
+			slot.result = result
+			close(slot.done)
 
 			mu.Lock()
 			results = append(results, result)
@@ -90,39 +187,183 @@ func (e *Executor) Execute(ctx context.Context, ip string, providers []Provider,
 	return results
 }
 
-// executeOne runs a single provider and returns the result.
+// executeGuarded runs a single provider behind its circuit breaker.
+func (e *Executor) executeGuarded(ctx context.Context, ip string, p Provider) *Result {
+	b := e.breaker(p.ID())
+	if b == nil {
+		return e.executeOne(ctx, ip, p)
+	}
+
+	if !b.allow() {
+		return NewErrorResult(p, 0, ErrCircuitOpen)
+	}
+
+	result := e.executeOne(ctx, ip, p)
+
+	if errors.Is(ctx.Err(), context.Canceled) {
+		b.release()
+	} else {
+		b.record(countsAsFailure(result))
+	}
+
+	return result
+}
+
+// breaker returns the circuit breaker for a provider, or nil if breakers are disabled.
+func (e *Executor) breaker(id string) *circuitBreaker {
+	if e.breakerThreshold <= 0 {
+		return nil
+	}
+
+	e.breakersMu.Lock()
+	defer e.breakersMu.Unlock()
+
+	b, ok := e.breakers[id]
+	if !ok {
+		b = newCircuitBreaker(e.breakerThreshold, e.breakerCooldown)
+		e.breakers[id] = b
+	}
+	return b
+}
+
+// CircuitState returns the current circuit breaker state for a provider.
+// Providers that have not run yet, or executors with breakers disabled, report CircuitClosed.
+func (e *Executor) CircuitState(id string) CircuitState {
+	e.breakersMu.Lock()
+	b, ok := e.breakers[id]
+	e.breakersMu.Unlock()
+
+	if !ok {
+		return CircuitClosed
+	}
+	return b.current()
+}
+
+// BreakerStates returns a snapshot of every provider's circuit breaker, for WithBreakerStates.
+func (e *Executor) BreakerStates() map[string]BreakerState {
+	e.breakersMu.Lock()
+	defer e.breakersMu.Unlock()
+
+	states := make(map[string]BreakerState, len(e.breakers))
+	for id, b := range e.breakers {
+		states[id] = b.snapshot()
+	}
+	return states
+}
+
+// executeOne runs a single provider and returns the result with timing metadata attached.
 func (e *Executor) executeOne(ctx context.Context, ip string, p Provider) *Result {
+	switch p := p.(type) {
+	case LookupProvider:
+		return e.executeLookup(ctx, ip, p)
+	case HTTPProvider:
+		return e.executeHTTP(ctx, ip, p)
+	default:
+		return NewErrorResult(p, 0, fmt.Errorf("provider %s has no lookup method", p.ID()))
+	}
+}
+
+// executeLookup runs a provider that performs its own lookup.
+// HTTP calls made through Fetch use the provider's client and body limit.
+func (e *Executor) executeLookup(ctx context.Context, ip string, p LookupProvider) *Result {
+	s := &session{
+		client: e.clientFor(p),
+		limit:  bodyLimit(p),
+	}
+	ctx = context.WithValue(ctx, sessionKey{}, s)
+	started := time.Now()
+
+	result, err := p.Lookup(ctx, ip)
+	if err != nil {
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			err = ctxErr
+		}
+		result = NewErrorResult(p, statusCodeOf(err), err)
+	}
+	if result == nil {
+		result = NewErrorResult(p, 0, errNoResult)
+	}
+
+	result.Timing = &Timing{StartedAt: started, Total: time.Since(started)}
+
+	s.mu.Lock()
+	result.ResponseSize = s.size
+	result.Truncated = s.truncated
+	s.mu.Unlock()
+
+	return result
+}
+
+// executeHTTP runs a provider that is answered by a single HTTP request.
+func (e *Executor) executeHTTP(ctx context.Context, ip string, p HTTPProvider) *Result {
 	req, err := p.BuildRequest(ctx, ip)
 	if err != nil {
 		return NewErrorResult(p, 0, fmt.Errorf("failed to build request: %w", err))
 	}
 
-	resp, err := e.client.Do(req)
+	t := newTracer()
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), t.clientTrace()))
+
+	result, size, truncated := e.do(req, p)
+	result.Timing = t.finish()
+	result.ResponseSize = size
+	result.Truncated = truncated
+
+	return result
+}
+
+// contextError returns a classified error if ctx has been cancelled or has expired.
+func contextError(ctx context.Context) error {
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		return NewError(ErrorKindCancelled, fmt.Errorf("operation cancelled"))
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return NewError(ErrorKindTimeout, fmt.Errorf("timeout exceeded"))
+	default:
+		return nil
+	}
+}
+
+// do sends the request and parses the response.
+// It also returns the number of body bytes read and whether the body was truncated.
+func (e *Executor) do(req *http.Request, p HTTPProvider) (*Result, int64, bool) {
+	resp, err := readResponse(e.clientFor(p), req, bodyLimit(p))
 	if err != nil {
-		if errors.Is(ctx.Err(), context.Canceled) {
-			return NewErrorResult(p, 0, fmt.Errorf("operation cancelled"))
+		if resp == nil {
+			return NewErrorResult(p, 0, err), 0, false
 		}
-		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-			return NewErrorResult(p, 0, fmt.Errorf("timeout exceeded"))
-		}
-		return NewErrorResult(p, 0, err)
+		return NewErrorResult(p, resp.StatusCode, err), int64(len(resp.Body)), isBodyTooLarge(err)
 	}
-	defer func() {
-		_ = resp.Body.Close()
-	}()
 
-	limitReader := io.LimitReader(resp.Body, MaxBodySize)
-	body, err := io.ReadAll(limitReader)
+	size := int64(len(resp.Body))
+
+	result, err := p.ParseResponse(resp.Body, resp.StatusCode)
 	if err != nil {
-		return NewErrorResult(p, resp.StatusCode, fmt.Errorf("read body failed: %w", err))
+		return NewErrorResult(p, resp.StatusCode, err), size, false
+	}
+	if result == nil {
+		return NewErrorResult(p, resp.StatusCode, errNoResult), size, false
 	}
 
-	result, err := p.ParseResponse(body, resp.StatusCode)
-	if err != nil {
-		return NewErrorResult(p, resp.StatusCode, err)
+	return result, size, false
+}
+
+// clientFor returns the HTTP client for a provider.
+func (e *Executor) clientFor(p Provider) *http.Client {
+	if c, ok := e.providerClients[p.ID()]; ok {
+		return c
 	}
+	return e.client
+}
 
-	return result
+// bodyLimit returns the response body limit for a provider.
+func bodyLimit(p Provider) int64 {
+	if bl, ok := p.(BodyLimiter); ok {
+		if limit := bl.BodyLimit(); limit > 0 {
+			return limit
+		}
+	}
+	return MaxBodySize
 }
 
 // ExecuteAsync starts provider execution and returns a channel of results.
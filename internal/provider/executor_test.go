@@ -0,0 +1,124 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// nilParser is an HTTPProvider whose ParseResponse returns neither a result nor an error.
+type nilParser struct {
+	url string
+}
+
+func (n nilParser) Name() string { return "nil" }
+func (n nilParser) ID() string   { return "nil" }
+
+func (n nilParser) BuildRequest(ctx context.Context, _ string) (*http.Request, error) {
+	return NewRequest(ctx, http.MethodGet, n.url, nil)
+}
+
+func (n nilParser) ParseResponse([]byte, int) (*Result, error) {
+	return nil, nil
+}
+
+// rawParser is an HTTPProvider that returns the response body as its result.
+type rawParser struct {
+	nilParser
+}
+
+func (r rawParser) ParseResponse(body []byte, statusCode int) (*Result, error) {
+	return NewSuccessResult(r, statusCode, string(body)), nil
+}
+
+func TestExecuteNilResult(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, "{}")
+	}))
+	defer srv.Close()
+
+	lookup := stubProvider{id: "nil-lookup", lookup: func(context.Context, string) (*Result, error) {
+		return nil, nil
+	}}
+
+	e := NewExecutor(WithCircuitBreaker(0, 0))
+	for _, p := range []Provider{lookup, nilParser{url: srv.URL}} {
+		results := e.Execute(context.Background(), "192.0.2.1", []Provider{p}, nil)
+		if len(results) != 1 {
+			t.Fatalf("%s: got %d results, want 1", p.ID(), len(results))
+		}
+		r := results[0]
+		if r.Err != errNoResult || r.ProviderID != p.ID() {
+			t.Errorf("%s: got error %v from %s, want %v", p.ID(), r.Err, r.ProviderID, errNoResult)
+		}
+		if r.Timing == nil {
+			t.Errorf("%s: timing not recorded", p.ID())
+		}
+	}
+}
+
+func TestExecuteTiming(t *testing.T) {
+	const delay = 20 * time.Millisecond
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(delay)
+		fmt.Fprint(w, "{}")
+	}))
+	defer srv.Close()
+
+	lookup := stubProvider{id: "lookup", lookup: func(context.Context, string) (*Result, error) {
+		time.Sleep(delay)
+		return NewSuccessResult(stubProvider{id: "lookup"}, 0, nil), nil
+	}}
+
+	tests := []struct {
+		name       string
+		p          Provider
+		wantPhases bool
+	}{
+		{name: "http", p: rawParser{nilParser{url: srv.URL}}, wantPhases: true},
+		{name: "lookup", p: lookup},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := NewExecutor(WithCircuitBreaker(0, 0)).Execute(context.Background(), "192.0.2.1", []Provider{tt.p}, nil)
+			r := results[0]
+			if r.Error != "" {
+				t.Fatalf("provider failed: %s", r.Error)
+			}
+
+			timing := r.Timing
+			if timing == nil || timing.StartedAt.IsZero() || timing.Total < delay {
+				t.Fatalf("Timing = %+v, want a start time and total of at least %v", timing, delay)
+			}
+			if tt.wantPhases && (timing.TTFB < delay || timing.TTFB > timing.Total || timing.Connect == 0) {
+				t.Errorf("TTFB %v connect %v, want TTFB between %v and total %v and a connect time", timing.TTFB, timing.Connect, delay, timing.Total)
+			}
+
+			data, err := json.Marshal(timing)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fields map[string]any
+			if err := json.Unmarshal(data, &fields); err != nil {
+				t.Fatal(err)
+			}
+			if total, _ := fields["total_ms"].(float64); total <= 0 {
+				t.Errorf("total_ms missing from %s", data)
+			}
+			for _, key := range []string{"dns_ms", "connect_ms", "tls_ms", "ttfb_ms"} {
+				if _, ok := fields[key]; ok != tt.wantPhases {
+					t.Errorf("%s present = %v, want %v in %s", key, ok, tt.wantPhases, data)
+				}
+			}
+			if ttfb, _ := fields["ttfb_ms"].(float64); tt.wantPhases && ttfb <= 0 {
+				t.Errorf("ttfb_ms not filled in: %s", data)
+			}
+		})
+	}
+}
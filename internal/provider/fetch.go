@@ -0,0 +1,125 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// Response is an HTTP response whose body has been read in full.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// session carries the executor's transport settings into a LookupProvider
+// and accumulates transfer metadata for its Result.
+type session struct {
+	client *http.Client
+	limit  int64
+
+	mu        sync.Mutex
+	size      int64
+	truncated bool
+}
+
+// sessionKey is the context key for the current session.
+type sessionKey struct{}
+
+// NewRequest creates an HTTP request with the headers every provider sends.
+func NewRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	// TODO: make this use the actual version not just a hardcoded string
+	req.Header.Set("User-Agent", "dalryan/ip-enrich")
+
+	return req, nil
+}
+
+// Fetch sends req for a LookupProvider that needs one or more HTTP calls.
+// Inside an executor it uses the client chosen for the calling provider (proxy, CA, mTLS)
+// and the provider's body limit, and counts the bytes read towards the provider's Result.
+// Outside an executor it falls back to http.DefaultClient and MaxBodySize.
+// A non-2xx status is not an error; check Response.StatusCode.
+func Fetch(ctx context.Context, req *http.Request) (*Response, error) {
+	client, limit := http.DefaultClient, int64(MaxBodySize)
+
+	s, _ := ctx.Value(sessionKey{}).(*session)
+	if s != nil {
+		client, limit = s.client, s.limit
+	}
+
+	resp, err := readResponse(client, req, limit)
+
+	if s != nil && resp != nil {
+		s.mu.Lock()
+		s.size += int64(len(resp.Body))
+		s.mu.Unlock()
+	}
+	if s != nil && isBodyTooLarge(err) {
+		s.mu.Lock()
+		s.truncated = true
+		s.mu.Unlock()
+	}
+
+	return resp, err
+}
+
+// readResponse sends req and reads at most limit bytes of the body.
+// If the body exceeds limit, the partial response is returned with a *BodyTooLargeError.
+func readResponse(client *http.Client, req *http.Request, limit int64) (*Response, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctxErr := contextError(req.Context()); ctxErr != nil {
+			return nil, ctxErr
+		}
+		// Transport errors quote the URL, which may carry an API key in its query.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = redactURL(req.URL)
+		}
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	// Read one byte past the limit so we can tell a full body from a truncated one.
+	limitReader := io.LimitReader(resp.Body, limit+1)
+	body, err := io.ReadAll(limitReader)
+
+	r := &Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+	}
+
+	if err != nil {
+		return r, NewError(ErrorKindNetwork, fmt.Errorf("read body failed: %w", err))
+	}
+
+	if int64(len(body)) > limit {
+		r.Body = body[:limit]
+		return r, &BodyTooLargeError{Limit: limit}
+	}
+
+	return r, nil
+}
+
+// redactURL returns u without its query string or credentials, for use in error messages.
+func redactURL(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+	redacted.RawQuery = ""
+	redacted.ForceQuery = false
+	return redacted.String()
+}
@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadResponseLimit(t *testing.T) {
+	const limit = 16
+
+	tests := []struct {
+		name      string
+		size      int
+		wantLen   int
+		wantLarge bool
+	}{
+		{name: "under the limit", size: limit - 1, wantLen: limit - 1},
+		{name: "exactly the limit", size: limit, wantLen: limit},
+		{name: "one byte over", size: limit + 1, wantLen: limit, wantLarge: true},
+		{name: "far over", size: 10 * limit, wantLen: limit, wantLarge: true},
+		{name: "empty", size: 0, wantLen: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusTeapot)
+				_, _ = w.Write([]byte(strings.Repeat("x", tt.size)))
+			}))
+			defer srv.Close()
+
+			req, err := NewRequest(t.Context(), http.MethodGet, srv.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := readResponse(srv.Client(), req, limit)
+
+			var tooLarge *BodyTooLargeError
+			if gotLarge := errors.As(err, &tooLarge); gotLarge != tt.wantLarge {
+				t.Fatalf("err = %v, want BodyTooLargeError %v", err, tt.wantLarge)
+			}
+			if tt.wantLarge && tooLarge.Limit != limit {
+				t.Errorf("Limit = %d, want %d", tooLarge.Limit, limit)
+			}
+			if !tt.wantLarge && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// The partial response is kept so providers can still report the status.
+			if resp == nil {
+				t.Fatal("got no response")
+			}
+			if resp.StatusCode != http.StatusTeapot || len(resp.Body) != tt.wantLen {
+				t.Errorf("got status %d and %d bytes, want %d and %d", resp.StatusCode, len(resp.Body), http.StatusTeapot, tt.wantLen)
+			}
+		})
+	}
+}
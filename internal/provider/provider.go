@@ -3,16 +3,24 @@ package provider
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 )
 
 // Provider defines the interface that all IP enrichment sources must implement.
+// A provider must also implement either HTTPProvider or LookupProvider.
 type Provider interface {
 	// Name returns a human-readable name (e.g., "Shodan")
 	Name() string
 
 	// ID returns a unique identifier (e.g., "shodan")
 	ID() string
+}
+
+// HTTPProvider is a Provider answered by a single HTTP request.
+// The executor sends the request, enforces the body limit and records timing.
+type HTTPProvider interface {
+	Provider
 
 	// BuildRequest constructs an HTTP request for the given IP address.
 	BuildRequest(ctx context.Context, ip string) (*http.Request, error)
@@ -21,6 +29,47 @@ type Provider interface {
 	ParseResponse(body []byte, statusCode int) (*Result, error)
 }
 
+// LookupProvider is a Provider that performs its own lookup, over any transport.
+type LookupProvider interface {
+	Provider
+
+	// Lookup queries the source for the given IP address.
+	Lookup(ctx context.Context, ip string) (*Result, error)
+}
+
+// Configurable is implemented by providers that accept settings from the config file.
+type Configurable interface {
+	// Configure applies the provider's raw JSON settings.
+	Configure(settings json.RawMessage) error
+}
+
+// Enabler is implemented by providers that can only run once configured,
+// e.g. with an API key or a local database. Disabled providers are skipped
+// when no providers are requested explicitly.
+type Enabler interface {
+	// Enabled reports whether the provider is ready to run.
+	Enabled() bool
+}
+
+// IsEnabled reports whether p is ready to run.
+// Providers that do not implement Enabler are always enabled.
+func IsEnabled(p Provider) bool {
+	if e, ok := p.(Enabler); ok {
+		return e.Enabled()
+	}
+	return true
+}
+
+// Dependent is implemented by providers that build on other providers' results,
+// e.g. a lookup keyed by the ASN another provider resolved. The executor starts a
+// dependent provider once its dependencies in the same run have finished, and
+// Filter adds enabled dependencies that were not requested as implicit providers.
+// Use Upstream to read their results.
+type Dependent interface {
+	// DependsOn returns the IDs of the providers whose results this provider reads.
+	DependsOn() []string
+}
+
 // Result represents the normalized output from any provider.
 type Result struct {
 	// ProviderID is the unique identifier of the provider that produced this result
@@ -35,6 +84,31 @@ type Result struct {
 	// Error contains any error message if Success is false
 	Error string `json:"error,omitempty"`
 
+	// ErrorKind is the machine-readable classification of Error
+	ErrorKind ErrorKind `json:"error_kind,omitempty"`
+
+	// Err is the underlying error, for use with errors.Is and errors.As
+	Err error `json:"-"`
+
+	// Verdict is the provider's reputation verdict for the IP, if it has one
+	Verdict Verdict `json:"verdict,omitempty"`
+
 	// Raw contains the original parsed response (provider-specific struct)
 	Raw any `json:"raw,omitempty"`
+
+	// Timing contains request latency metadata, if a request was made
+	Timing *Timing `json:"timing,omitempty"`
+
+	// ResponseSize is the number of response body bytes read
+	ResponseSize int64 `json:"response_size"`
+
+	// Truncated is true if the response body exceeded the provider's body limit
+	Truncated bool `json:"truncated,omitempty"`
+
+	// Warnings lists problems that did not fail the lookup, e.g. a failed secondary request
+	Warnings []string `json:"warnings,omitempty"`
+
+	// Implicit is true if the provider was not requested and only ran because another
+	// provider depends on it. Implicit results don't count towards the verdict or exit code.
+	Implicit bool `json:"implicit,omitempty"`
 }
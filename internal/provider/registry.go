@@ -1,6 +1,7 @@
 package provider
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
 	"sync"
@@ -23,12 +24,18 @@ func NewRegistry() *Registry {
 }
 
 // Register adds a provider to the registry.
-// Panics if a provider with the same ID is already registered.
+// Panics if a provider with the same ID is already registered,
+// or if it implements neither HTTPProvider nor LookupProvider.
 func (r *Registry) Register(p Provider) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	id := p.ID()
+	_, isHTTP := p.(HTTPProvider)
+	_, isLookup := p.(LookupProvider)
+	if !isHTTP && !isLookup {
+		panic(fmt.Sprintf("provider %s implements neither HTTPProvider nor LookupProvider", id))
+	}
 	if _, exists := r.providers[id]; exists {
 		panic(fmt.Sprintf("provider already registered: %s", id))
 	}
@@ -74,35 +81,62 @@ func (r *Registry) IDs() []string {
 	return ids
 }
 
-// Filter returns providers matching the given IDs.
-// If ids is empty, returns all providers.
-func (r *Registry) Filter(ids []string) []Provider {
+// Filter returns providers matching the given IDs, plus the enabled providers they depend on.
+// If ids is empty, returns all enabled providers and their dependencies.
+// Providers that only run because another depends on them are reported in implicit.
+func (r *Registry) Filter(ids []string) (providers []Provider, implicit map[string]bool) {
 	if len(ids) == 0 {
-		return r.All()
+		for _, p := range r.All() {
+			if IsEnabled(p) {
+				ids = append(ids, p.ID())
+			}
+		}
+		if len(ids) == 0 {
+			return nil, nil
+		}
 	}
 
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	requested := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		requested[id] = true
+	}
 	seen := make(map[string]struct{})
-	providers := make([]Provider, 0, len(ids))
+	implicit = make(map[string]bool)
+
+	// Dependencies join the queue so their own dependencies are pulled in too.
+	queue := append([]string(nil), ids...)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
 
-	for _, id := range ids {
 		if _, alreadySeen := seen[id]; alreadySeen {
 			continue
 		}
 		seen[id] = struct{}{}
 
-		if p, ok := r.providers[id]; ok {
-			providers = append(providers, p)
+		p, ok := r.providers[id]
+		if !ok {
+			continue
+		}
+		if !requested[id] {
+			// Unlike requested providers, dependencies only run if they are ready to.
+			if !IsEnabled(p) {
+				continue
+			}
+			implicit[id] = true
 		}
+		providers = append(providers, p)
+		queue = append(queue, dependencies(p)...)
 	}
 
 	sort.Slice(providers, func(i, j int) bool {
 		return providers[i].Name() < providers[j].Name()
 	})
 
-	return providers
+	return providers, implicit
 }
 
 // Validate validates a list of providers by their IDs
@@ -119,6 +153,30 @@ func (r *Registry) Validate(ids []string) []string {
 	return unknown
 }
 
+// Configure applies per-provider settings keyed by provider ID.
+// Returns an error for unknown providers and for providers that take no settings.
+func (r *Registry) Configure(settings map[string]json.RawMessage) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for id, raw := range settings {
+		p, ok := r.providers[id]
+		if !ok {
+			return fmt.Errorf("settings for unknown provider: %s", id)
+		}
+
+		c, ok := p.(Configurable)
+		if !ok {
+			return fmt.Errorf("provider %s does not take settings", id)
+		}
+
+		if err := c.Configure(raw); err != nil {
+			return fmt.Errorf("provider %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
 // Register adds a provider to the default registry.
 func Register(p Provider) {
 	defaultRegistry.Register(p)
@@ -140,7 +198,7 @@ func IDs() []string {
 }
 
 // Filter returns filtered providers from the default registry.
-func Filter(ids []string) []Provider {
+func Filter(ids []string) ([]Provider, map[string]bool) {
 	return defaultRegistry.Filter(ids)
 }
 
@@ -148,3 +206,8 @@ func Filter(ids []string) []Provider {
 func Validate(ids []string) []string {
 	return defaultRegistry.Validate(ids)
 }
+
+// Configure applies per-provider settings to the default registry.
+func Configure(settings map[string]json.RawMessage) error {
+	return defaultRegistry.Configure(settings)
+}
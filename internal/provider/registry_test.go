@@ -0,0 +1,63 @@
+package provider
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+// disabledStub is a stubProvider that is not ready to run.
+type disabledStub struct {
+	stubProvider
+}
+
+func (disabledStub) Enabled() bool { return false }
+
+func newTestRegistry(providers ...Provider) *Registry {
+	r := NewRegistry()
+	for _, p := range providers {
+		r.Register(p)
+	}
+	return r
+}
+
+func TestFilterDependencies(t *testing.T) {
+	r := newTestRegistry(
+		stubProvider{id: "a", deps: []string{"b"}},
+		stubProvider{id: "b", deps: []string{"c"}},
+		stubProvider{id: "c"},
+		stubProvider{id: "d", deps: []string{"e", "missing"}},
+		disabledStub{stubProvider{id: "e"}},
+		disabledStub{stubProvider{id: "f", deps: []string{"c"}}},
+	)
+
+	tests := []struct {
+		name         string
+		ids          []string
+		want         []string
+		wantImplicit []string
+	}{
+		{name: "dependencies of dependencies", ids: []string{"a"}, want: []string{"a", "b", "c"}, wantImplicit: []string{"b", "c"}},
+		{name: "requested dependency is not implicit", ids: []string{"a", "c"}, want: []string{"a", "b", "c"}, wantImplicit: []string{"b"}},
+		{name: "disabled and unknown dependencies are skipped", ids: []string{"d"}, want: []string{"d"}},
+		{name: "requested disabled provider runs", ids: []string{"e", "f"}, want: []string{"c", "e", "f"}, wantImplicit: []string{"c"}},
+		{name: "all enabled", ids: nil, want: []string{"a", "b", "c", "d"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers, implicit := r.Filter(tt.ids)
+
+			var got []string
+			for _, p := range providers {
+				got = append(got, p.ID())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("providers = %v, want %v", got, tt.want)
+			}
+			if gotImplicit := slices.Sorted(maps.Keys(implicit)); !slices.Equal(gotImplicit, tt.wantImplicit) {
+				t.Errorf("implicit = %v, want %v", gotImplicit, tt.wantImplicit)
+			}
+		})
+	}
+}
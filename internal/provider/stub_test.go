@@ -0,0 +1,22 @@
+package provider
+
+import "context"
+
+// stubProvider is a LookupProvider whose behaviour is set per test.
+// With no lookup func it succeeds with no data.
+type stubProvider struct {
+	id     string
+	deps   []string
+	lookup func(ctx context.Context, ip string) (*Result, error)
+}
+
+func (s stubProvider) Name() string        { return s.id }
+func (s stubProvider) ID() string          { return s.id }
+func (s stubProvider) DependsOn() []string { return s.deps }
+
+func (s stubProvider) Lookup(ctx context.Context, ip string) (*Result, error) {
+	if s.lookup == nil {
+		return NewSuccessResult(s, 0, nil), nil
+	}
+	return s.lookup(ctx, ip)
+}
@@ -0,0 +1,130 @@
+package provider
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timing records where the time went for a single provider request.
+// Phases that did not happen (e.g. DNS on a reused connection) are zero.
+// Lookup providers may make several requests, or none, so only their total is recorded.
+type Timing struct {
+	StartedAt time.Time
+	DNS       time.Duration
+	Connect   time.Duration
+	TLS       time.Duration
+	TTFB      time.Duration
+	Total     time.Duration
+
+	// traced is set when the phases were measured, so untraced ones are omitted rather than zero.
+	traced bool
+}
+
+// timingPhases are the per-phase durations in milliseconds.
+type timingPhases struct {
+	DNS     float64 `json:"dns_ms"`
+	Connect float64 `json:"connect_ms"`
+	TLS     float64 `json:"tls_ms"`
+	TTFB    float64 `json:"ttfb_ms"`
+}
+
+// MarshalJSON renders durations as fractional milliseconds.
+func (t Timing) MarshalJSON() ([]byte, error) {
+	out := struct {
+		StartedAt time.Time `json:"started_at"`
+		*timingPhases
+		Total float64 `json:"total_ms"`
+	}{
+		StartedAt: t.StartedAt,
+		Total:     millis(t.Total),
+	}
+	if t.traced {
+		out.timingPhases = &timingPhases{
+			DNS:     millis(t.DNS),
+			Connect: millis(t.Connect),
+			TLS:     millis(t.TLS),
+			TTFB:    millis(t.TTFB),
+		}
+	}
+	return json.Marshal(out)
+}
+
+// millis converts a duration to milliseconds.
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// tracer collects httptrace events into a Timing.
+// Trace hooks may fire from multiple goroutines, so all access is guarded.
+type tracer struct {
+	mu           sync.Mutex
+	timing       Timing
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+}
+
+// newTracer starts timing a request now.
+func newTracer() *tracer {
+	return &tracer{
+		timing: Timing{StartedAt: time.Now(), traced: true},
+	}
+}
+
+// clientTrace returns the httptrace hooks that feed this tracer.
+func (t *tracer) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			t.dnsStart = time.Now()
+			t.mu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			t.timing.DNS = time.Since(t.dnsStart)
+			t.mu.Unlock()
+		},
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+			t.mu.Unlock()
+		},
+		ConnectDone: func(_, _ string, err error) {
+			t.mu.Lock()
+			if err == nil && t.timing.Connect == 0 {
+				t.timing.Connect = time.Since(t.connectStart)
+			}
+			t.mu.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			t.tlsStart = time.Now()
+			t.mu.Unlock()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mu.Lock()
+			t.timing.TLS = time.Since(t.tlsStart)
+			t.mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			t.timing.TTFB = time.Since(t.timing.StartedAt)
+			t.mu.Unlock()
+		},
+	}
+}
+
+// finish stops the clock and returns the collected timing.
+func (t *tracer) finish() *Timing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timing := t.timing
+	timing.Total = time.Since(timing.StartedAt)
+	return &timing
+}
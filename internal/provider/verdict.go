@@ -0,0 +1,45 @@
+package provider
+
+// Verdict is a provider's coarse opinion of an IP.
+// Providers that carry no reputation signal leave it empty.
+type Verdict string
+
+const (
+	// VerdictBenign means the provider positively identifies the IP as harmless.
+	VerdictBenign Verdict = "benign"
+	// VerdictSuspicious means the provider has some adverse signal for the IP.
+	VerdictSuspicious Verdict = "suspicious"
+	// VerdictMalicious means the provider classifies the IP as malicious.
+	VerdictMalicious Verdict = "malicious"
+)
+
+// severity orders verdicts from no opinion to malicious.
+func (v Verdict) severity() int {
+	switch v {
+	case VerdictBenign:
+		return 1
+	case VerdictSuspicious:
+		return 2
+	case VerdictMalicious:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// MaxVerdict returns the more severe of two verdicts.
+func MaxVerdict(a, b Verdict) Verdict {
+	if b.severity() > a.severity() {
+		return b
+	}
+	return a
+}
+
+// WorstVerdict returns the most severe verdict across results.
+func WorstVerdict(results []*Result) Verdict {
+	var worst Verdict
+	for _, r := range results {
+		worst = MaxVerdict(worst, r.Verdict)
+	}
+	return worst
+}
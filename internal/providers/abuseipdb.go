@@ -0,0 +1,128 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+const (
+	// abuseIPDBDefaultMaxAge is the report window AbuseIPDB itself defaults to.
+	abuseIPDBDefaultMaxAge = 30
+
+	// abuseIPDBMaliciousScore and abuseIPDBSuspiciousScore map the abuse confidence score to a verdict.
+	abuseIPDBMaliciousScore  = 75
+	abuseIPDBSuspiciousScore = 25
+)
+
+// AbuseIPDBResponse represents the response from the AbuseIPDB check endpoint.
+type AbuseIPDBResponse struct {
+	Data struct {
+		IPAddress            string   `json:"ipAddress"`
+		IsPublic             bool     `json:"isPublic"`
+		IPVersion            int      `json:"ipVersion"`
+		IsWhitelisted        *bool    `json:"isWhitelisted"`
+		AbuseConfidenceScore int      `json:"abuseConfidenceScore"`
+		CountryCode          string   `json:"countryCode"`
+		UsageType            string   `json:"usageType"`
+		ISP                  string   `json:"isp"`
+		Domain               string   `json:"domain"`
+		Hostnames            []string `json:"hostnames"`
+		IsTor                bool     `json:"isTor"`
+		TotalReports         int      `json:"totalReports"`
+		NumDistinctUsers     int      `json:"numDistinctUsers"`
+		LastReportedAt       string   `json:"lastReportedAt"`
+	} `json:"data"`
+}
+
+// abuseIPDBError is the error body AbuseIPDB returns with non-2xx statuses.
+type abuseIPDBError struct {
+	Errors []struct {
+		Detail string `json:"detail"`
+	} `json:"errors"`
+}
+
+// AbuseIPDBSettings are the config file settings for the AbuseIPDB provider.
+type AbuseIPDBSettings struct {
+	provider.KeySettings
+
+	// MaxAgeInDays limits reports to the last N days (1-365, default 30).
+	MaxAgeInDays int `json:"max_age_in_days"`
+}
+
+// AbuseIPDB implements the Provider interface for the AbuseIPDB check API.
+type AbuseIPDB struct {
+	provider.BaseProvider
+}
+
+// NewAbuseIPDB creates a new AbuseIPDB provider. It is disabled until an API key is configured.
+func NewAbuseIPDB() *AbuseIPDB {
+	return &AbuseIPDB{
+		BaseProvider: provider.BaseProvider{
+			ProviderName: "AbuseIPDB",
+			ProviderID:   "abuseipdb",
+			URLTemplate:  abuseIPDBURL(abuseIPDBDefaultMaxAge),
+			Auth:         provider.Auth{Scheme: provider.AuthHeader, Name: "Key"},
+		},
+	}
+}
+
+// Configure applies the provider's settings.
+func (a *AbuseIPDB) Configure(settings json.RawMessage) error {
+	var s AbuseIPDBSettings
+	if err := json.Unmarshal(settings, &s); err != nil {
+		return err
+	}
+
+	maxAge := s.MaxAgeInDays
+	if maxAge == 0 {
+		maxAge = abuseIPDBDefaultMaxAge
+	}
+	if maxAge < 1 || maxAge > 365 {
+		return fmt.Errorf("max_age_in_days must be between 1 and 365, got %d", maxAge)
+	}
+
+	s.KeySettings.Apply(&a.Auth)
+	a.URLTemplate = abuseIPDBURL(maxAge)
+	return nil
+}
+
+// ParseResponse parses the AbuseIPDB check response.
+func (a *AbuseIPDB) ParseResponse(body []byte, statusCode int) (*provider.Result, error) {
+	if statusCode != http.StatusOK {
+		err := provider.NewStatusError(statusCode)
+		var apiErr abuseIPDBError
+		if json.Unmarshal(body, &apiErr) == nil && len(apiErr.Errors) > 0 {
+			err.Err = fmt.Errorf("%w: %s", err.Err, apiErr.Errors[0].Detail)
+		}
+		return provider.NewErrorResult(a, statusCode, err), nil
+	}
+
+	var resp AbuseIPDBResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, provider.NewParseError(err)
+	}
+
+	result := provider.NewSuccessResult(a, statusCode, resp)
+	switch score := resp.Data.AbuseConfidenceScore; {
+	case score >= abuseIPDBMaliciousScore:
+		result.Verdict = provider.VerdictMalicious
+	case score >= abuseIPDBSuspiciousScore:
+		result.Verdict = provider.VerdictSuspicious
+	case resp.Data.IsWhitelisted != nil && *resp.Data.IsWhitelisted:
+		result.Verdict = provider.VerdictBenign
+	}
+
+	return result, nil
+}
+
+// abuseIPDBURL returns the check URL template for the given report window.
+func abuseIPDBURL(maxAge int) string {
+	return fmt.Sprintf("https://api.abuseipdb.com/api/v2/check?ipAddress={ip}&maxAgeInDays=%d", maxAge)
+}
+
+func init() {
+	provider.Register(NewAbuseIPDB())
+}
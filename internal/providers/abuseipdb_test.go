@@ -0,0 +1,67 @@
+package providers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+func TestAbuseIPDBParseResponse(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantKind    provider.ErrorKind
+		wantError   string
+		wantVerdict provider.Verdict
+	}{
+		{name: "malicious", status: 200, body: `{"data":{"ipAddress":"192.0.2.1","abuseConfidenceScore":100,"totalReports":812}}`, wantVerdict: provider.VerdictMalicious},
+		{name: "malicious threshold", status: 200, body: `{"data":{"abuseConfidenceScore":75}}`, wantVerdict: provider.VerdictMalicious},
+		{name: "suspicious", status: 200, body: `{"data":{"abuseConfidenceScore":25,"isWhitelisted":true}}`, wantVerdict: provider.VerdictSuspicious},
+		{name: "whitelisted", status: 200, body: `{"data":{"ipAddress":"8.8.8.8","abuseConfidenceScore":0,"isWhitelisted":true}}`, wantVerdict: provider.VerdictBenign},
+		{name: "never reported", status: 200, body: `{"data":{"ipAddress":"192.0.2.1","abuseConfidenceScore":0,"isWhitelisted":null,"totalReports":0}}`},
+		{name: "low score", status: 200, body: `{"data":{"abuseConfidenceScore":24,"isWhitelisted":false}}`},
+		{
+			name:      "invalid key",
+			status:    401,
+			body:      `{"errors":[{"detail":"Authentication failed. Your API key is either missing, incorrect, or revoked.","status":401}]}`,
+			wantKind:  provider.ErrorKindAuth,
+			wantError: "Authentication failed",
+		},
+		{
+			name:      "invalid parameter",
+			status:    422,
+			body:      `{"errors":[{"detail":"The max age in days must be between 1 and 365.","status":422,"source":{"parameter":"maxAgeInDays"}}]}`,
+			wantKind:  provider.ErrorKindHTTPStatus,
+			wantError: "The max age in days must be between 1 and 365.",
+		},
+		{name: "daily limit", status: 429, body: `{"errors":[{"detail":"Daily rate limit of 1000 requests exceeded for this endpoint."}]}`, wantKind: provider.ErrorKindRateLimited, wantError: "Daily rate limit"},
+		{name: "error without body", status: 503, wantKind: provider.ErrorKindHTTPStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewAbuseIPDB().ParseResponse([]byte(tt.body), tt.status)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.ErrorKind != tt.wantKind {
+				t.Fatalf("ErrorKind = %q, want %q (%s)", result.ErrorKind, tt.wantKind, result.Error)
+			}
+			if !strings.Contains(result.Error, tt.wantError) {
+				t.Errorf("Error = %q, want it to contain %q", result.Error, tt.wantError)
+			}
+			if result.Verdict != tt.wantVerdict {
+				t.Errorf("Verdict = %q, want %q", result.Verdict, tt.wantVerdict)
+			}
+		})
+	}
+}
+
+func TestAbuseIPDBParseResponseMalformed(t *testing.T) {
+	_, err := NewAbuseIPDB().ParseResponse([]byte(`{"data":`), 200)
+	if got := provider.KindOf(err); got != provider.ErrorKindParse {
+		t.Errorf("error kind = %q, want %q (%v)", got, provider.ErrorKindParse, err)
+	}
+}
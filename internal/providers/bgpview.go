@@ -0,0 +1,208 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strconv"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// bgpviewBaseURL is the BGPView API root.
+const bgpviewBaseURL = "https://api.bgpview.io/"
+
+// BGPViewResponse represents the response from the BGPView IP API,
+// with the upstreams and RPKI status of the most specific prefix added.
+type BGPViewResponse struct {
+	Status        string `json:"status"`
+	StatusMessage string `json:"status_message"`
+	Data          struct {
+		IP        string `json:"ip"`
+		PTRRecord string `json:"ptr_record"`
+		Prefixes  []struct {
+			Prefix string `json:"prefix"`
+			ASN    struct {
+				ASN         int    `json:"asn"`
+				Name        string `json:"name"`
+				Description string `json:"description"`
+				CountryCode string `json:"country_code"`
+			} `json:"asn"`
+			Name        string `json:"name"`
+			Description string `json:"description"`
+			CountryCode string `json:"country_code"`
+		} `json:"prefixes"`
+		RIRAllocation struct {
+			RIRName          string `json:"rir_name"`
+			CountryCode      string `json:"country_code"`
+			Prefix           string `json:"prefix"`
+			DateAllocated    string `json:"date_allocated"`
+			AllocationStatus string `json:"allocation_status"`
+		} `json:"rir_allocation"`
+	} `json:"data"`
+
+	// Prefix is the most specific announced prefix, which Upstreams and RPKI describe.
+	Prefix string `json:"prefix,omitempty"`
+	// Upstreams are the ASes seen upstream of each origin of Prefix.
+	Upstreams []BGPViewASN `json:"upstreams,omitempty"`
+	// RPKI is BGPView's ROA status for Prefix and its first origin (e.g. "Valid", "None").
+	RPKI string `json:"rpki,omitempty"`
+}
+
+// BGPViewASN is an AS as BGPView describes it.
+type BGPViewASN struct {
+	ASN         int    `json:"asn"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	CountryCode string `json:"country_code"`
+}
+
+// bgpviewEnvelope wraps every BGPView API response.
+type bgpviewEnvelope[T any] struct {
+	Status        string `json:"status"`
+	StatusMessage string `json:"status_message"`
+	Data          T      `json:"data"`
+}
+
+// BGPView implements the LookupProvider interface for the BGPView API.
+type BGPView struct {
+	provider.BaseProvider
+}
+
+// NewBGPView creates a new BGPView provider.
+func NewBGPView() *BGPView {
+	return &BGPView{
+		BaseProvider: provider.BaseProvider{
+			ProviderName: "BGPView",
+			ProviderID:   "bgpview",
+		},
+	}
+}
+
+// Lookup fetches the IP's prefixes, then the upstreams and ROA status of the most specific one.
+// A failed follow-up call is reported as a warning rather than failing the lookup.
+func (b *BGPView) Lookup(ctx context.Context, ip string) (*provider.Result, error) {
+	var resp BGPViewResponse
+	statusCode, err := getJSON(ctx, bgpviewBaseURL+"ip/"+ip, nil, &resp)
+	if err == nil && resp.Status != "ok" {
+		err = provider.NewError(provider.ErrorKindAPI, fmt.Errorf("API returned status=%s: %s", resp.Status, resp.StatusMessage))
+	}
+	if err != nil {
+		return provider.NewErrorResult(b, statusCode, err), nil
+	}
+
+	result := provider.NewSuccessResult(b, statusCode, nil)
+
+	resp.Prefix = mostSpecificPrefix(resp)
+	if resp.Prefix != "" {
+		upstreams, err := b.upstreams(ctx, resp.Prefix)
+		if err != nil {
+			result.Warnings = append(result.Warnings, "upstreams: "+err.Error())
+		}
+		resp.Upstreams = upstreams
+
+		rpki, err := b.roaStatus(ctx, resp.Prefix, originOf(resp, resp.Prefix))
+		if err != nil {
+			result.Warnings = append(result.Warnings, "rpki: "+err.Error())
+		}
+		resp.RPKI = rpki
+	}
+
+	result.Raw = resp
+	return result, nil
+}
+
+// upstreams returns the upstreams of every origin of prefix, without duplicates.
+func (b *BGPView) upstreams(ctx context.Context, prefix string) ([]BGPViewASN, error) {
+	data, err := bgpviewCall[struct {
+		ASNs []struct {
+			PrefixUpstreams []BGPViewASN `json:"prefix_upstreams"`
+		} `json:"asns"`
+	}](ctx, "prefix/"+prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var upstreams []BGPViewASN
+	seen := make(map[int]bool)
+	for _, origin := range data.ASNs {
+		for _, up := range origin.PrefixUpstreams {
+			if !seen[up.ASN] {
+				seen[up.ASN] = true
+				upstreams = append(upstreams, up)
+			}
+		}
+	}
+	return upstreams, nil
+}
+
+// roaStatus returns BGPView's ROA status for prefix as announced by asn.
+// BGPView only publishes it in the origin's prefix list.
+func (b *BGPView) roaStatus(ctx context.Context, prefix string, asn int) (string, error) {
+	if asn == 0 {
+		return "", nil
+	}
+
+	data, err := bgpviewCall[struct {
+		IPv4 []bgpviewROA `json:"ipv4_prefixes"`
+		IPv6 []bgpviewROA `json:"ipv6_prefixes"`
+	}](ctx, "asn/"+strconv.Itoa(asn)+"/prefixes")
+	if err != nil {
+		return "", err
+	}
+
+	for _, p := range append(data.IPv4, data.IPv6...) {
+		if p.Prefix == prefix {
+			return p.ROAStatus, nil
+		}
+	}
+	return "", nil
+}
+
+// bgpviewROA is a prefix in an AS's prefix list, with its ROA status.
+type bgpviewROA struct {
+	Prefix    string `json:"prefix"`
+	ROAStatus string `json:"roa_status"`
+}
+
+// bgpviewCall fetches a BGPView endpoint and returns its data payload.
+func bgpviewCall[T any](ctx context.Context, path string) (T, error) {
+	var envelope bgpviewEnvelope[T]
+	if _, err := getJSON(ctx, bgpviewBaseURL+path, nil, &envelope); err != nil {
+		return envelope.Data, err
+	}
+	if envelope.Status != "ok" {
+		err := fmt.Errorf("API returned status=%s: %s", envelope.Status, envelope.StatusMessage)
+		return envelope.Data, provider.NewError(provider.ErrorKindAPI, err)
+	}
+	return envelope.Data, nil
+}
+
+// mostSpecificPrefix returns the longest announced prefix covering the IP, or "".
+func mostSpecificPrefix(resp BGPViewResponse) string {
+	best, bestBits := "", -1
+	for _, p := range resp.Data.Prefixes {
+		parsed, err := netip.ParsePrefix(p.Prefix)
+		if err != nil {
+			continue
+		}
+		if parsed.Bits() > bestBits {
+			best, bestBits = p.Prefix, parsed.Bits()
+		}
+	}
+	return best
+}
+
+// originOf returns the origin ASN BGPView lists for prefix, or zero.
+func originOf(resp BGPViewResponse, prefix string) int {
+	for _, p := range resp.Data.Prefixes {
+		if p.Prefix == prefix {
+			return p.ASN.ASN
+		}
+	}
+	return 0
+}
+
+func init() {
+	provider.Register(NewBGPView())
+}
@@ -0,0 +1,85 @@
+package providers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+const bgpviewIPBody = `{"status":"ok","status_message":"Query was successful","data":{"ip":"1.1.1.1","prefixes":[
+	{"prefix":"1.0.0.0/8","asn":{"asn":1,"name":"BROAD"}},
+	{"prefix":"1.1.1.0/24","asn":{"asn":13335,"name":"CLOUDFLARENET"}}]}}`
+
+func TestBGPViewLookup(t *testing.T) {
+	tests := []struct {
+		name          string
+		routes        map[string]string
+		wantUpstreams []int
+		wantRPKI      string
+		wantWarnings  int
+	}{
+		{
+			name: "upstreams and rpki",
+			routes: map[string]string{
+				"/ip/1.1.1.1": bgpviewIPBody,
+				"/prefix/1.1.1.0/24": `{"status":"ok","data":{"asns":[
+					{"asn":13335,"prefix_upstreams":[{"asn":174,"name":"COGENT"},{"asn":3356,"name":"LEVEL3"}]},
+					{"asn":13335,"prefix_upstreams":[{"asn":174,"name":"COGENT"}]}]}}`,
+				"/asn/13335/prefixes": `{"status":"ok","data":{"ipv4_prefixes":[
+					{"prefix":"1.0.0.0/24","roa_status":"Valid"},{"prefix":"1.1.1.0/24","roa_status":"Valid"}],"ipv6_prefixes":[]}}`,
+			},
+			wantUpstreams: []int{174, 3356},
+			wantRPKI:      "Valid",
+		},
+		{
+			name:         "follow-up calls fail",
+			routes:       map[string]string{"/ip/1.1.1.1": bgpviewIPBody},
+			wantWarnings: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := runAgainst(t, NewBGPView(), "1.1.1.1", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, ok := tt.routes[r.URL.Path]
+				if !ok {
+					http.NotFound(w, r)
+					return
+				}
+				fmt.Fprint(w, body)
+			}))
+
+			if result.Error != "" {
+				t.Fatalf("unexpected error: %s", result.Error)
+			}
+			if len(result.Warnings) != tt.wantWarnings {
+				t.Errorf("warnings = %q, want %d", result.Warnings, tt.wantWarnings)
+			}
+
+			resp := result.Raw.(BGPViewResponse)
+			if resp.Prefix != "1.1.1.0/24" {
+				t.Errorf("Prefix = %q, want the most specific prefix", resp.Prefix)
+			}
+			var upstreams []int
+			for _, u := range resp.Upstreams {
+				upstreams = append(upstreams, u.ASN)
+			}
+			if fmt.Sprint(upstreams) != fmt.Sprint(tt.wantUpstreams) {
+				t.Errorf("upstreams = %v, want %v", upstreams, tt.wantUpstreams)
+			}
+			if resp.RPKI != tt.wantRPKI {
+				t.Errorf("RPKI = %q, want %q", resp.RPKI, tt.wantRPKI)
+			}
+		})
+	}
+}
+
+func TestBGPViewLookupKeepsStatusCode(t *testing.T) {
+	result := runAgainst(t, NewBGPView(), "1.1.1.1", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+
+	if result.StatusCode != http.StatusTooManyRequests || result.ErrorKind != "rate_limited" {
+		t.Errorf("got status %d kind %q, want 429 rate_limited", result.StatusCode, result.ErrorKind)
+	}
+}
@@ -0,0 +1,180 @@
+package providers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// CensysResponse is the Censys host record with per-port services.
+type CensysResponse struct {
+	IP          string        `json:"ip"`
+	ASN         int           `json:"asn,omitempty"`
+	ASName      string        `json:"as_name,omitempty"`
+	Prefix      string        `json:"prefix,omitempty"`
+	Country     string        `json:"country,omitempty"`
+	City        string        `json:"city,omitempty"`
+	OS          string        `json:"os,omitempty"`
+	Hostnames   []string      `json:"hostnames"`
+	LastUpdated string        `json:"last_updated"`
+	Services    []HostService `json:"services"`
+}
+
+// censysHost is the subset of the v2 hosts response we read.
+type censysHost struct {
+	Result struct {
+		IP       string `json:"ip"`
+		Location struct {
+			Country string `json:"country"`
+			City    string `json:"city"`
+		} `json:"location"`
+		AutonomousSystem struct {
+			ASN       int    `json:"asn"`
+			Name      string `json:"name"`
+			BGPPrefix string `json:"bgp_prefix"`
+		} `json:"autonomous_system"`
+		OperatingSystem *censysSoftware `json:"operating_system"`
+		DNS             struct {
+			ReverseDNS struct {
+				Names []string `json:"names"`
+			} `json:"reverse_dns"`
+		} `json:"dns"`
+		LastUpdatedAt string `json:"last_updated_at"`
+		Services      []struct {
+			Port                int              `json:"port"`
+			ServiceName         string           `json:"service_name"`
+			ExtendedServiceName string           `json:"extended_service_name"`
+			TransportProtocol   string           `json:"transport_protocol"`
+			Software            []censysSoftware `json:"software"`
+			Banner              string           `json:"banner"`
+			ObservedAt          string           `json:"observed_at"`
+			HTTP                *struct {
+				Response struct {
+					HTMLTitle string `json:"html_title"`
+				} `json:"response"`
+			} `json:"http"`
+			TLS *struct {
+				Certificates struct {
+					LeafFPSHA256 string `json:"leaf_fp_sha_256"`
+					LeafData     struct {
+						SubjectDN string   `json:"subject_dn"`
+						IssuerDN  string   `json:"issuer_dn"`
+						Names     []string `json:"names"`
+					} `json:"leaf_data"`
+				} `json:"certificates"`
+			} `json:"tls"`
+		} `json:"services"`
+	} `json:"result"`
+}
+
+// censysSoftware is a product Censys identified, with its CPE.
+type censysSoftware struct {
+	URI     string `json:"uniform_resource_identifier"`
+	Vendor  string `json:"vendor"`
+	Product string `json:"product"`
+	Version string `json:"version"`
+}
+
+// Censys implements the Provider interface for the Censys Search v2 hosts API.
+type Censys struct {
+	provider.BaseProvider
+}
+
+// NewCensys creates a new Censys provider. It is disabled until API credentials are configured.
+func NewCensys() *Censys {
+	return &Censys{
+		BaseProvider: provider.BaseProvider{
+			ProviderName: "Censys",
+			ProviderID:   "censys",
+			URLTemplate:  "https://search.censys.io/api/v2/hosts/{ip}",
+			// The API ID and secret are sent as basic auth.
+			Auth:    provider.Auth{Scheme: provider.AuthBasic},
+			MaxBody: 20 * 1024 * 1024,
+		},
+	}
+}
+
+// Configure applies the provider's settings. api_key is the API ID and api_secret the secret.
+func (c *Censys) Configure(settings json.RawMessage) error {
+	var s provider.KeySettings
+	if err := json.Unmarshal(settings, &s); err != nil {
+		return err
+	}
+	s.Apply(&c.Auth)
+	return nil
+}
+
+// ParseResponse parses the Censys hosts response.
+func (c *Censys) ParseResponse(body []byte, statusCode int) (*provider.Result, error) {
+	// Censys returns 404 for hosts it has not seen
+	if statusCode == http.StatusNotFound {
+		return provider.NewSuccessResult(c, statusCode, nil), nil
+	}
+
+	if statusCode != http.StatusOK {
+		return provider.NewErrorResult(c, statusCode, scannerStatusError(statusCode, body)), nil
+	}
+
+	var host censysHost
+	if err := json.Unmarshal(body, &host); err != nil {
+		return nil, provider.NewParseError(err)
+	}
+
+	r := host.Result
+	resp := CensysResponse{
+		IP:          r.IP,
+		ASN:         r.AutonomousSystem.ASN,
+		ASName:      r.AutonomousSystem.Name,
+		Prefix:      r.AutonomousSystem.BGPPrefix,
+		Country:     r.Location.Country,
+		City:        r.Location.City,
+		Hostnames:   nonNil(r.DNS.ReverseDNS.Names),
+		LastUpdated: r.LastUpdatedAt,
+		Services:    make([]HostService, 0, len(r.Services)),
+	}
+	if r.OperatingSystem != nil {
+		resp.OS = r.OperatingSystem.Product
+	}
+
+	for _, s := range r.Services {
+		svc := HostService{
+			Port:      s.Port,
+			Transport: s.TransportProtocol,
+			Service:   s.ExtendedServiceName,
+			Banner:    truncateBanner(s.Banner),
+			LastSeen:  s.ObservedAt,
+		}
+		if svc.Service == "" {
+			svc.Service = s.ServiceName
+		}
+		for i, sw := range s.Software {
+			if sw.URI != "" {
+				svc.Software = append(svc.Software, sw.URI)
+			}
+			// The first identified product is the service's own.
+			if i == 0 {
+				svc.Product, svc.Version = sw.Product, sw.Version
+			}
+		}
+		if s.HTTP != nil {
+			svc.HTTPTitle = s.HTTP.Response.HTMLTitle
+		}
+		if s.TLS != nil {
+			leaf := s.TLS.Certificates
+			svc.Certificate = &TLSCertificate{
+				Subject: leaf.LeafData.SubjectDN,
+				Issuer:  leaf.LeafData.IssuerDN,
+				Names:   leaf.LeafData.Names,
+				SHA256:  leaf.LeafFPSHA256,
+			}
+		}
+		resp.Services = append(resp.Services, svc)
+	}
+
+	return provider.NewSuccessResult(c, statusCode, resp), nil
+}
+
+func init() {
+	provider.Register(NewCensys())
+}
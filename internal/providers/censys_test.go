@@ -0,0 +1,76 @@
+package providers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+func TestCensysParseResponse(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		wantKind  provider.ErrorKind
+		wantError string
+		wantData  bool
+	}{
+		{
+			name:   "host with services",
+			status: 200,
+			body: `{"code":200,"status":"OK","result":{"ip":"192.0.2.1",
+				"autonomous_system":{"asn":64500,"name":"EXAMPLE","bgp_prefix":"192.0.2.0/24"},
+				"operating_system":{"product":"Linux"},"dns":{"reverse_dns":{"names":["host.example.com"]}},
+				"services":[
+					{"port":22,"service_name":"SSH","transport_protocol":"TCP","banner":"SSH-2.0-OpenSSH_9.6",
+						"software":[{"uniform_resource_identifier":"cpe:2.3:a:openbsd:openssh:9.6","product":"openssh","version":"9.6"},
+							{"uniform_resource_identifier":"cpe:2.3:o:canonical:ubuntu_linux","product":"ubuntu_linux"}]},
+					{"port":443,"service_name":"HTTP","extended_service_name":"HTTPS","transport_protocol":"TCP",
+						"http":{"response":{"html_title":"Welcome"}},
+						"tls":{"certificates":{"leaf_fp_sha_256":"ab12","leaf_data":{"subject_dn":"CN=example.com","issuer_dn":"C=US, O=Let's Encrypt, CN=R3","names":["example.com"]}}}}]}}`,
+			wantData: true,
+		},
+		{name: "not seen", status: 404, body: `{"code":404,"status":"Not Found","error":"The requested host could not be found."}`},
+		{name: "invalid credentials", status: 401, body: `{"code":401,"status":"Unauthorized","error":"You must authenticate with a valid API ID and secret."}`, wantKind: provider.ErrorKindAuth, wantError: "valid API ID and secret"},
+		{name: "quota", status: 429, body: `{"code":429,"status":"Too Many Requests","error":"Rate limit exceeded"}`, wantKind: provider.ErrorKindRateLimited, wantError: "Rate limit exceeded"},
+		{name: "error without body", status: 500, wantKind: provider.ErrorKindHTTPStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewCensys().ParseResponse([]byte(tt.body), tt.status)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.ErrorKind != tt.wantKind {
+				t.Fatalf("ErrorKind = %q, want %q (%s)", result.ErrorKind, tt.wantKind, result.Error)
+			}
+			if !strings.Contains(result.Error, tt.wantError) {
+				t.Errorf("Error = %q, want it to contain %q", result.Error, tt.wantError)
+			}
+			// Scan data describes exposure, not reputation.
+			if result.Verdict != "" {
+				t.Errorf("Verdict = %q, want none", result.Verdict)
+			}
+			if (result.Raw != nil) != tt.wantData {
+				t.Fatalf("Raw = %+v, want data %v", result.Raw, tt.wantData)
+			}
+			if !tt.wantData {
+				return
+			}
+
+			resp := result.Raw.(CensysResponse)
+			if resp.ASN != 64500 || resp.OS != "Linux" || len(resp.Hostnames) != 1 || len(resp.Services) != 2 {
+				t.Fatalf("unexpected host: %+v", resp)
+			}
+			ssh, https := resp.Services[0], resp.Services[1]
+			if ssh.Service != "SSH" || ssh.Product != "openssh" || ssh.Version != "9.6" || len(ssh.Software) != 2 {
+				t.Errorf("ssh service = %+v", ssh)
+			}
+			if https.Service != "HTTPS" || https.HTTPTitle != "Welcome" || https.Certificate == nil || https.Certificate.Subject != "CN=example.com" {
+				t.Errorf("https service = %+v", https)
+			}
+		})
+	}
+}
@@ -0,0 +1,417 @@
+package providers
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/dalryan/ip-enrich/internal/iptrie"
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// cloudParsers read each cloud's published range file, keyed by cloud name.
+var cloudParsers = map[string]func(data []byte) ([]cloudRange, error){
+	"aws":        parseAWSRanges,
+	"gcp":        parseGCPRanges,
+	"azure":      parseAzureRanges,
+	"cloudflare": parseCloudflareRanges,
+	"oracle":     parseOracleRanges,
+	"fastly":     parseFastlyRanges,
+	"github":     parseGitHubRanges,
+}
+
+// cloudGenericServices are catch-all services that cover a cloud's whole address space.
+// A specific service on the same prefix is preferred over them.
+var cloudGenericServices = map[string]bool{
+	"AMAZON":     true,
+	"AzureCloud": true,
+}
+
+// CloudResponse attributes the IP to a cloud, region and service.
+// Cloud, Region, Service and Prefix describe the most specific match.
+type CloudResponse struct {
+	IP      string            `json:"ip"`
+	Cloud   string            `json:"cloud"`
+	Region  string            `json:"region,omitempty"`
+	Service string            `json:"service,omitempty"`
+	Prefix  string            `json:"prefix"`
+	Matches []CloudMatch      `json:"matches"`
+	Errors  map[string]string `json:"errors,omitempty"`
+}
+
+// CloudMatch is a single published range that covers the IP.
+type CloudMatch struct {
+	Cloud   string `json:"cloud"`
+	Region  string `json:"region,omitempty"`
+	Service string `json:"service,omitempty"`
+	Prefix  string `json:"prefix"`
+}
+
+// CloudSettings are the config file settings for the cloud ranges provider.
+type CloudSettings struct {
+	// Ranges maps a cloud (aws, gcp, azure, cloudflare, oracle, fastly, github)
+	// to its published range file, relative to the data directory unless absolute.
+	Ranges map[string]string `json:"ranges"`
+}
+
+// cloudRange is one prefix from a range file.
+type cloudRange struct {
+	CloudMatch
+	prefix netip.Prefix
+}
+
+// loadedCloud pairs a cloud with its reloadable ranges.
+type loadedCloud struct {
+	name   string
+	ranges *fileCache[*iptrie.Trie[cloudRange]]
+}
+
+// CloudRanges implements the LookupProvider interface for published cloud IP ranges.
+type CloudRanges struct {
+	provider.BaseProvider
+	clouds []*loadedCloud
+}
+
+// NewCloudRanges creates a new cloud ranges provider. It is disabled until range files are configured.
+func NewCloudRanges() *CloudRanges {
+	return &CloudRanges{
+		BaseProvider: provider.BaseProvider{
+			ProviderName: "Cloud IP Ranges",
+			ProviderID:   "cloud",
+		},
+	}
+}
+
+// Configure applies the provider's settings.
+func (c *CloudRanges) Configure(settings json.RawMessage) error {
+	var s CloudSettings
+	if err := json.Unmarshal(settings, &s); err != nil {
+		return err
+	}
+
+	clouds := make([]*loadedCloud, 0, len(s.Ranges))
+	for name, path := range s.Ranges {
+		parse, ok := cloudParsers[name]
+		if !ok {
+			return fmt.Errorf("unknown cloud %q (supported: %s)", name, strings.Join(cloudNames(), ", "))
+		}
+		if path == "" {
+			return fmt.Errorf("cloud %s: path is empty", name)
+		}
+
+		clouds = append(clouds, &loadedCloud{
+			name:   name,
+			ranges: newFileCache(provider.DataPath(path), loadCloudRanges(name, parse)),
+		})
+	}
+	slices.SortFunc(clouds, func(a, b *loadedCloud) int { return cmp.Compare(a.name, b.name) })
+
+	c.clouds = clouds
+	return nil
+}
+
+// Enabled reports whether any range files are configured.
+func (c *CloudRanges) Enabled() bool {
+	return len(c.clouds) > 0
+}
+
+// Lookup finds every published range covering the IP.
+func (c *CloudRanges) Lookup(_ context.Context, ip string) (*provider.Result, error) {
+	if !c.Enabled() {
+		return nil, errors.New("no cloud range files configured")
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IP address: %s", ip)
+	}
+
+	var matches []cloudRange
+	errs := make(map[string]string)
+	for _, cloud := range c.clouds {
+		ranges, err := cloud.ranges.get()
+		if err != nil {
+			errs[cloud.name] = err.Error()
+			continue
+		}
+		matches = append(matches, ranges.Match(addr)...)
+	}
+
+	if len(errs) == len(c.clouds) {
+		return nil, fmt.Errorf("no cloud range files could be loaded: %v", errs)
+	}
+	if len(matches) == 0 {
+		result := provider.NewSuccessResult(c, 0, nil)
+		result.Warnings = cloudWarnings(errs)
+		return result, nil
+	}
+
+	// Most specific first; on equal prefixes, specific services before catch-alls.
+	sort.SliceStable(matches, func(i, j int) bool {
+		if bi, bj := matches[i].prefix.Bits(), matches[j].prefix.Bits(); bi != bj {
+			return bi > bj
+		}
+		return !cloudGenericServices[matches[i].Service] && cloudGenericServices[matches[j].Service]
+	})
+
+	best := matches[0]
+	resp := CloudResponse{
+		IP:      ip,
+		Cloud:   best.Cloud,
+		Region:  best.Region,
+		Service: best.Service,
+		Prefix:  best.Prefix,
+		Matches: make([]CloudMatch, 0, len(matches)),
+	}
+	if len(errs) > 0 {
+		resp.Errors = errs
+	}
+	for _, m := range matches {
+		resp.Matches = append(resp.Matches, m.CloudMatch)
+	}
+
+	result := provider.NewSuccessResult(c, 0, resp)
+	result.Warnings = cloudWarnings(errs)
+	return result, nil
+}
+
+// cloudWarnings lists the range files that failed to load, in cloud order.
+func cloudWarnings(errs map[string]string) []string {
+	var warnings []string
+	for _, name := range cloudNames() {
+		if msg, ok := errs[name]; ok {
+			warnings = append(warnings, name+": "+msg)
+		}
+	}
+	return warnings
+}
+
+// cloudNames returns the supported clouds in order.
+func cloudNames() []string {
+	names := make([]string, 0, len(cloudParsers))
+	for name := range cloudParsers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// loadCloudRanges returns a loader that parses a range file into a trie.
+func loadCloudRanges(cloud string, parse func([]byte) ([]cloudRange, error)) func(path string) (*iptrie.Trie[cloudRange], error) {
+	return func(path string) (*iptrie.Trie[cloudRange], error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		ranges, err := parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s ranges: %w", cloud, err)
+		}
+
+		trie := iptrie.New[cloudRange]()
+		for _, r := range ranges {
+			r.Cloud = cloud
+			r.Prefix = r.prefix.String()
+			trie.Insert(r.prefix, r)
+		}
+		return trie, nil
+	}
+}
+
+// appendRange parses prefix and appends it to ranges, skipping malformed entries.
+func appendRange(ranges []cloudRange, prefix, region, service string) []cloudRange {
+	p, err := iptrie.ParsePrefix(strings.TrimSpace(prefix))
+	if err != nil {
+		return ranges
+	}
+	return append(ranges, cloudRange{
+		CloudMatch: CloudMatch{Region: region, Service: service},
+		prefix:     p.Masked(),
+	})
+}
+
+// parseAWSRanges reads https://ip-ranges.amazonaws.com/ip-ranges.json.
+func parseAWSRanges(data []byte) ([]cloudRange, error) {
+	var file struct {
+		Prefixes []struct {
+			IPPrefix string `json:"ip_prefix"`
+			Region   string `json:"region"`
+			Service  string `json:"service"`
+		} `json:"prefixes"`
+		IPv6Prefixes []struct {
+			IPv6Prefix string `json:"ipv6_prefix"`
+			Region     string `json:"region"`
+			Service    string `json:"service"`
+		} `json:"ipv6_prefixes"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	var ranges []cloudRange
+	for _, p := range file.Prefixes {
+		ranges = appendRange(ranges, p.IPPrefix, p.Region, p.Service)
+	}
+	for _, p := range file.IPv6Prefixes {
+		ranges = appendRange(ranges, p.IPv6Prefix, p.Region, p.Service)
+	}
+	return ranges, nil
+}
+
+// parseGCPRanges reads https://www.gstatic.com/ipranges/cloud.json.
+func parseGCPRanges(data []byte) ([]cloudRange, error) {
+	var file struct {
+		Prefixes []struct {
+			IPv4Prefix string `json:"ipv4Prefix"`
+			IPv6Prefix string `json:"ipv6Prefix"`
+			Service    string `json:"service"`
+			Scope      string `json:"scope"`
+		} `json:"prefixes"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	var ranges []cloudRange
+	for _, p := range file.Prefixes {
+		prefix := p.IPv4Prefix
+		if prefix == "" {
+			prefix = p.IPv6Prefix
+		}
+		ranges = appendRange(ranges, prefix, p.Scope, p.Service)
+	}
+	return ranges, nil
+}
+
+// parseAzureRanges reads the weekly ServiceTags_Public_<date>.json download.
+func parseAzureRanges(data []byte) ([]cloudRange, error) {
+	var file struct {
+		Values []struct {
+			Name       string `json:"name"`
+			Properties struct {
+				Region          string   `json:"region"`
+				SystemService   string   `json:"systemService"`
+				AddressPrefixes []string `json:"addressPrefixes"`
+			} `json:"properties"`
+		} `json:"values"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	var ranges []cloudRange
+	for _, v := range file.Values {
+		// Tags are named "<service>" or "<service>.<region>".
+		service := v.Properties.SystemService
+		if service == "" {
+			service, _, _ = strings.Cut(v.Name, ".")
+		}
+		for _, prefix := range v.Properties.AddressPrefixes {
+			ranges = appendRange(ranges, prefix, v.Properties.Region, service)
+		}
+	}
+	return ranges, nil
+}
+
+// parseCloudflareRanges reads https://api.cloudflare.com/client/v4/ips,
+// or the plain https://www.cloudflare.com/ips-v4 and ips-v6 lists.
+func parseCloudflareRanges(data []byte) ([]cloudRange, error) {
+	var file struct {
+		Result struct {
+			IPv4CIDRs []string `json:"ipv4_cidrs"`
+			IPv6CIDRs []string `json:"ipv6_cidrs"`
+		} `json:"result"`
+	}
+
+	cidrs := parseTextFeed(data)
+	if err := json.Unmarshal(data, &file); err == nil {
+		cidrs = append(file.Result.IPv4CIDRs, file.Result.IPv6CIDRs...)
+	}
+
+	var ranges []cloudRange
+	for _, cidr := range cidrs {
+		ranges = appendRange(ranges, cidr, "", "")
+	}
+	return ranges, nil
+}
+
+// parseOracleRanges reads https://docs.oracle.com/en-us/iaas/tools/public_ip_ranges.json.
+func parseOracleRanges(data []byte) ([]cloudRange, error) {
+	var file struct {
+		Regions []struct {
+			Region string `json:"region"`
+			CIDRs  []struct {
+				CIDR string   `json:"cidr"`
+				Tags []string `json:"tags"`
+			} `json:"cidrs"`
+		} `json:"regions"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	var ranges []cloudRange
+	for _, r := range file.Regions {
+		for _, c := range r.CIDRs {
+			ranges = appendRange(ranges, c.CIDR, r.Region, strings.Join(c.Tags, ","))
+		}
+	}
+	return ranges, nil
+}
+
+// parseFastlyRanges reads https://api.fastly.com/public-ip-list.
+func parseFastlyRanges(data []byte) ([]cloudRange, error) {
+	var file struct {
+		Addresses     []string `json:"addresses"`
+		IPv6Addresses []string `json:"ipv6_addresses"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	var ranges []cloudRange
+	for _, cidr := range append(file.Addresses, file.IPv6Addresses...) {
+		ranges = appendRange(ranges, cidr, "", "")
+	}
+	return ranges, nil
+}
+
+// parseGitHubRanges reads https://api.github.com/meta, where every list of CIDRs is a service
+// (hooks, web, api, git, actions, pages, ...).
+func parseGitHubRanges(data []byte) ([]cloudRange, error) {
+	var file map[string]json.RawMessage
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	services := make([]string, 0, len(file))
+	for service := range file {
+		services = append(services, service)
+	}
+	slices.Sort(services)
+
+	var ranges []cloudRange
+	for _, service := range services {
+		var cidrs []string
+		// Other keys hold booleans, fingerprints or domain lists; only CIDR lists parse as prefixes.
+		if err := json.Unmarshal(file[service], &cidrs); err != nil {
+			continue
+		}
+		for _, cidr := range cidrs {
+			ranges = appendRange(ranges, cidr, "", service)
+		}
+	}
+	return ranges, nil
+}
+
+func init() {
+	provider.Register(NewCloudRanges())
+}
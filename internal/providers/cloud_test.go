@@ -0,0 +1,141 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCloudParsers(t *testing.T) {
+	tests := []struct {
+		cloud string
+		file  string
+		want  int
+	}{
+		{cloud: "aws", file: "aws.json", want: 5},
+		{cloud: "gcp", file: "gcp.json", want: 3},
+		{cloud: "azure", file: "azure.json", want: 5},
+		{cloud: "cloudflare", file: "cloudflare.json", want: 3},
+		{cloud: "cloudflare", file: "cloudflare-ips-v4.txt", want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "cloud", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ranges, err := cloudParsers[tt.cloud](data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ranges) != tt.want {
+				t.Errorf("got %d ranges, want %d: %+v", len(ranges), tt.want, ranges)
+			}
+		})
+	}
+
+	for _, cloud := range []string{"aws", "gcp", "azure"} {
+		if _, err := cloudParsers[cloud]([]byte("<html>")); err == nil {
+			t.Errorf("%s: malformed file was accepted", cloud)
+		}
+	}
+}
+
+func TestCloudLookup(t *testing.T) {
+	fixture := func(name string) string {
+		path, err := filepath.Abs(filepath.Join("testdata", "cloud", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	settings, err := json.Marshal(CloudSettings{Ranges: map[string]string{
+		"aws":        fixture("aws.json"),
+		"gcp":        fixture("gcp.json"),
+		"azure":      fixture("azure.json"),
+		"cloudflare": fixture("cloudflare.json"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewCloudRanges()
+	if err := p.Configure(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ip          string
+		wantCloud   string
+		wantRegion  string
+		wantService string
+		wantPrefix  string
+		wantMatches int
+	}{
+		// A specific service is preferred over AMAZON on the same prefix.
+		{ip: "3.5.140.1", wantCloud: "aws", wantRegion: "ap-northeast-2", wantService: "S3", wantPrefix: "3.5.140.0/22", wantMatches: 2},
+		// A longer prefix wins over a shorter one.
+		{ip: "52.94.76.5", wantCloud: "aws", wantRegion: "us-west-2", wantService: "EC2", wantPrefix: "52.94.76.0/22", wantMatches: 2},
+		{ip: "52.94.1.1", wantCloud: "aws", wantRegion: "us-east-1", wantService: "AMAZON", wantPrefix: "52.94.0.0/16", wantMatches: 1},
+		{ip: "2600:1f14::1", wantCloud: "aws", wantRegion: "us-west-2", wantService: "EC2", wantPrefix: "2600:1f14::/35", wantMatches: 1},
+		{ip: "34.1.210.9", wantCloud: "gcp", wantRegion: "africa-south1", wantService: "Google Cloud", wantPrefix: "34.1.208.0/20", wantMatches: 1},
+		{ip: "2600:1900:8000::1", wantCloud: "gcp", wantRegion: "us-central1", wantService: "Google Cloud", wantPrefix: "2600:1900:8000::/44", wantMatches: 1},
+		{ip: "20.33.1.1", wantCloud: "azure", wantRegion: "eastus", wantService: "AzureStorage", wantPrefix: "20.33.0.0/16", wantMatches: 3},
+		{ip: "20.38.98.7", wantCloud: "azure", wantRegion: "eastus", wantService: "AzureStorage", wantPrefix: "20.38.98.0/24", wantMatches: 1},
+		{ip: "2603:1000::1", wantCloud: "azure", wantService: "AzureCloud", wantPrefix: "2603:1000::/40", wantMatches: 1},
+		{ip: "104.18.1.1", wantCloud: "cloudflare", wantPrefix: "104.16.0.0/13", wantMatches: 1},
+		{ip: "2606:4700::1111", wantCloud: "cloudflare", wantPrefix: "2606:4700::/32", wantMatches: 1},
+		{ip: "192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			result, err := p.Lookup(context.Background(), tt.ip)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Warnings) > 0 {
+				t.Errorf("warnings: %v", result.Warnings)
+			}
+			if tt.wantCloud == "" {
+				if result.Raw != nil {
+					t.Errorf("got %+v, want no match", result.Raw)
+				}
+				return
+			}
+
+			resp := result.Raw.(CloudResponse)
+			if resp.Cloud != tt.wantCloud || resp.Region != tt.wantRegion || resp.Service != tt.wantService ||
+				resp.Prefix != tt.wantPrefix || len(resp.Matches) != tt.wantMatches {
+				t.Errorf("got %s/%s/%s %s with %d matches, want %s/%s/%s %s with %d",
+					resp.Cloud, resp.Region, resp.Service, resp.Prefix, len(resp.Matches),
+					tt.wantCloud, tt.wantRegion, tt.wantService, tt.wantPrefix, tt.wantMatches)
+			}
+		})
+	}
+}
+
+func TestCloudLookupMissingFile(t *testing.T) {
+	aws, err := filepath.Abs(filepath.Join("testdata", "cloud", "aws.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings, _ := json.Marshal(CloudSettings{Ranges: map[string]string{
+		"aws": aws,
+		"gcp": filepath.Join(t.TempDir(), "missing.json"),
+	}})
+	p := NewCloudRanges()
+	if err := p.Configure(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := p.Lookup(context.Background(), "52.94.1.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp := result.Raw.(CloudResponse); resp.Cloud != "aws" || resp.Errors["gcp"] == "" || len(result.Warnings) != 1 {
+		t.Errorf("got %+v, warnings %v", resp, result.Warnings)
+	}
+}
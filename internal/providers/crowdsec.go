@@ -0,0 +1,131 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// CrowdSecResponse represents the response from the CrowdSec CTI smoke endpoint.
+type CrowdSecResponse struct {
+	IP                   string          `json:"ip"`
+	Reputation           string          `json:"reputation"`
+	Confidence           string          `json:"confidence"`
+	IPRange              string          `json:"ip_range"`
+	IPRangeScore         int             `json:"ip_range_score"`
+	ASName               string          `json:"as_name"`
+	ASNum                int             `json:"as_num"`
+	ReverseDNS           string          `json:"reverse_dns"`
+	BackgroundNoise      string          `json:"background_noise"`
+	BackgroundNoiseScore int             `json:"background_noise_score"`
+	Behaviors            []CrowdSecLabel `json:"behaviors"`
+	AttackDetails        []CrowdSecLabel `json:"attack_details"`
+	MitreTechniques      []CrowdSecLabel `json:"mitre_techniques"`
+	CVEs                 []string        `json:"cves"`
+	Classifications      struct {
+		Classifications []CrowdSecLabel `json:"classifications"`
+		FalsePositives  []CrowdSecLabel `json:"false_positives"`
+	} `json:"classifications"`
+	TargetCountries map[string]int `json:"target_countries"`
+	Location        struct {
+		Country string `json:"country"`
+		City    string `json:"city"`
+	} `json:"location"`
+	History struct {
+		FirstSeen string `json:"first_seen"`
+		LastSeen  string `json:"last_seen"`
+		FullAge   int    `json:"full_age"`
+		DaysAge   int    `json:"days_age"`
+	} `json:"history"`
+	Scores struct {
+		Overall CrowdSecScores `json:"overall"`
+		LastDay CrowdSecScores `json:"last_day"`
+	} `json:"scores"`
+}
+
+// CrowdSecLabel is a named behavior, classification or attack detail.
+type CrowdSecLabel struct {
+	Name        string `json:"name"`
+	Label       string `json:"label"`
+	Description string `json:"description,omitempty"`
+}
+
+// CrowdSecScores are CrowdSec's 0-5 scores for one time window.
+type CrowdSecScores struct {
+	Aggressiveness int `json:"aggressiveness"`
+	Threat         int `json:"threat"`
+	Trust          int `json:"trust"`
+	Anomaly        int `json:"anomaly"`
+	Total          int `json:"total"`
+}
+
+// CrowdSec implements the Provider interface for the CrowdSec CTI API.
+type CrowdSec struct {
+	provider.BaseProvider
+}
+
+// NewCrowdSec creates a new CrowdSec provider. It is disabled until an API key is configured.
+func NewCrowdSec() *CrowdSec {
+	return &CrowdSec{
+		BaseProvider: provider.BaseProvider{
+			ProviderName: "CrowdSec CTI",
+			ProviderID:   "crowdsec",
+			URLTemplate:  "https://cti.api.crowdsec.net/v2/smoke/{ip}",
+			Auth:         provider.Auth{Scheme: provider.AuthHeader, Name: "x-api-key"},
+		},
+	}
+}
+
+// Configure applies the provider's settings.
+func (c *CrowdSec) Configure(settings json.RawMessage) error {
+	var s provider.KeySettings
+	if err := json.Unmarshal(settings, &s); err != nil {
+		return err
+	}
+	s.Apply(&c.Auth)
+	return nil
+}
+
+// ParseResponse parses the CrowdSec smoke response.
+func (c *CrowdSec) ParseResponse(body []byte, statusCode int) (*provider.Result, error) {
+	// CrowdSec returns 404 for IPs its network has not reported
+	if statusCode == http.StatusNotFound {
+		return provider.NewSuccessResult(c, statusCode, nil), nil
+	}
+
+	if statusCode != http.StatusOK {
+		err := provider.NewStatusError(statusCode)
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+			err.Err = fmt.Errorf("%w: %s", err.Err, apiErr.Message)
+		}
+		return provider.NewErrorResult(c, statusCode, err), nil
+	}
+
+	var resp CrowdSecResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, provider.NewParseError(err)
+	}
+
+	// Reputation is one of malicious, suspicious, known, safe or unknown. "known" IPs have
+	// been reported without enough signal to judge, so they get no verdict like "unknown".
+	result := provider.NewSuccessResult(c, statusCode, resp)
+	switch {
+	case resp.Reputation == "malicious":
+		result.Verdict = provider.VerdictMalicious
+	case resp.Reputation == "suspicious":
+		result.Verdict = provider.VerdictSuspicious
+	case resp.Reputation == "safe" || len(resp.Classifications.FalsePositives) > 0:
+		result.Verdict = provider.VerdictBenign
+	}
+
+	return result, nil
+}
+
+func init() {
+	provider.Register(NewCrowdSec())
+}
@@ -0,0 +1,80 @@
+package providers
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// crowdSecSmoke returns a smoke response with the given reputation and false positives.
+func crowdSecSmoke(reputation, falsePositives string) string {
+	return fmt.Sprintf(`{"ip":"192.0.2.1","reputation":%q,"confidence":"high","as_num":64500,
+		"behaviors":[{"name":"ssh:bruteforce","label":"SSH Bruteforce"}],
+		"classifications":{"classifications":[],"false_positives":[%s]},
+		"target_countries":{"US":40,"DE":20},"background_noise_score":7}`, reputation, falsePositives)
+}
+
+func TestCrowdSecParseResponse(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantKind    provider.ErrorKind
+		wantError   string
+		wantVerdict provider.Verdict
+		wantData    bool
+	}{
+		{name: "malicious", status: 200, body: crowdSecSmoke("malicious", ""), wantVerdict: provider.VerdictMalicious, wantData: true},
+		{name: "suspicious", status: 200, body: crowdSecSmoke("suspicious", ""), wantVerdict: provider.VerdictSuspicious, wantData: true},
+		{name: "safe", status: 200, body: crowdSecSmoke("safe", ""), wantVerdict: provider.VerdictBenign, wantData: true},
+		{name: "known", status: 200, body: crowdSecSmoke("known", ""), wantData: true},
+		{name: "unknown", status: 200, body: crowdSecSmoke("unknown", ""), wantData: true},
+		{
+			name:        "known false positive",
+			status:      200,
+			body:        crowdSecSmoke("known", `{"name":"seo:crawler","label":"SEO crawler"}`),
+			wantVerdict: provider.VerdictBenign,
+			wantData:    true,
+		},
+		{
+			name:        "malicious despite false positive",
+			status:      200,
+			body:        crowdSecSmoke("malicious", `{"name":"seo:crawler","label":"SEO crawler"}`),
+			wantVerdict: provider.VerdictMalicious,
+			wantData:    true,
+		},
+		{name: "never reported", status: 404, body: `{"message":"IP address information not found"}`},
+		{name: "invalid key", status: 403, body: `{"message":"Forbidden"}`, wantKind: provider.ErrorKindAuth, wantError: "Forbidden"},
+		{name: "quota", status: 429, body: `{"message":"Too Many Requests"}`, wantKind: provider.ErrorKindRateLimited, wantError: "Too Many Requests"},
+		{name: "error without body", status: 500, wantKind: provider.ErrorKindHTTPStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewCrowdSec().ParseResponse([]byte(tt.body), tt.status)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.ErrorKind != tt.wantKind {
+				t.Fatalf("ErrorKind = %q, want %q (%s)", result.ErrorKind, tt.wantKind, result.Error)
+			}
+			if !strings.Contains(result.Error, tt.wantError) {
+				t.Errorf("Error = %q, want it to contain %q", result.Error, tt.wantError)
+			}
+			if result.Verdict != tt.wantVerdict {
+				t.Errorf("Verdict = %q, want %q", result.Verdict, tt.wantVerdict)
+			}
+			if (result.Raw != nil) != tt.wantData {
+				t.Fatalf("Raw = %+v, want data %v", result.Raw, tt.wantData)
+			}
+			if tt.wantData {
+				resp := result.Raw.(CrowdSecResponse)
+				if resp.ASNum != 64500 || len(resp.Behaviors) != 1 || resp.TargetCountries["US"] != 40 {
+					t.Errorf("unexpected response: %+v", resp)
+				}
+			}
+		})
+	}
+}
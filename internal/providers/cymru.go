@@ -0,0 +1,296 @@
+package providers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// CymruResponse represents the IP-to-ASN mapping from Team Cymru.
+type CymruResponse struct {
+	IP          string `json:"ip"`
+	ASN         int    `json:"asn"`
+	OriginASNs  []int  `json:"origin_asns"`
+	ASName      string `json:"as_name"`
+	Prefix      string `json:"prefix"`
+	CountryCode string `json:"country_code"`
+	Registry    string `json:"registry"`
+	Allocated   string `json:"allocated"`
+	Peers       []int  `json:"peers,omitempty"`
+}
+
+// CymruSettings are the config file settings for the Team Cymru provider.
+type CymruSettings struct {
+	// Whois queries whois.cymru.com on port 43 instead of the DNS origin zone.
+	Whois bool `json:"whois"`
+
+	// Resolver is a DNS server (host:port) to query instead of the system resolver.
+	Resolver string `json:"resolver"`
+}
+
+// txtResolver looks up TXT records. *net.Resolver implements it; tests substitute their own.
+type txtResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// Cymru implements the LookupProvider interface for Team Cymru's IP-to-ASN service.
+type Cymru struct {
+	provider.BaseProvider
+	settings CymruSettings
+	resolver txtResolver
+}
+
+// NewCymru creates a new Team Cymru provider.
+func NewCymru() *Cymru {
+	return &Cymru{
+		BaseProvider: provider.BaseProvider{
+			ProviderName: "Team Cymru",
+			ProviderID:   "cymru",
+		},
+		resolver: net.DefaultResolver,
+	}
+}
+
+// Configure applies the provider's settings.
+func (c *Cymru) Configure(settings json.RawMessage) error {
+	if err := json.Unmarshal(settings, &c.settings); err != nil {
+		return err
+	}
+	c.resolver = newResolver(c.settings.Resolver)
+	return nil
+}
+
+// Lookup resolves the IP's origin and peer ASNs.
+// A failed peers query is reported as a warning rather than failing the lookup.
+func (c *Cymru) Lookup(ctx context.Context, ip string) (*provider.Result, error) {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return nil, fmt.Errorf("invalid IP address: %s", ip)
+	}
+
+	var (
+		resp *CymruResponse
+		err  error
+	)
+	if c.settings.Whois {
+		resp, err = c.whois(ctx, addr)
+	} else {
+		resp, err = c.origin(ctx, addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Unannounced space has no origin
+	if resp == nil {
+		return provider.NewSuccessResult(c, 0, nil), nil
+	}
+
+	result := provider.NewSuccessResult(c, 0, nil)
+
+	// Peers are only published for IPv4.
+	if addr.To4() != nil {
+		records, err := c.resolver.LookupTXT(ctx, reverseIP(addr)+".peer.asn.cymru.com")
+		if err != nil && !isNotFound(err) {
+			result.Warnings = append(result.Warnings, "peers: "+err.Error())
+		}
+		resp.Peers = parseCymruPeers(records)
+	}
+
+	result.Raw = *resp
+	return result, nil
+}
+
+// origin queries the origin(6).asn.cymru.com zone, then the AS name from asn.cymru.com.
+// Returns nil if the IP has no origin.
+func (c *Cymru) origin(ctx context.Context, addr net.IP) (*CymruResponse, error) {
+	zone := "origin.asn.cymru.com"
+	if addr.To4() == nil {
+		zone = "origin6.asn.cymru.com"
+	}
+
+	records, err := c.resolver.LookupTXT(ctx, reverseIP(addr)+"."+zone)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := parseCymruOrigin(addr.String(), records)
+	if resp == nil || err != nil || resp.ASN == 0 {
+		return resp, err
+	}
+
+	records, err = c.resolver.LookupTXT(ctx, fmt.Sprintf("AS%d.asn.cymru.com", resp.ASN))
+	if err != nil && !isNotFound(err) {
+		return nil, err
+	}
+	resp.ASName = parseCymruASName(records)
+
+	return resp, nil
+}
+
+// parseCymruOrigin reads origin zone records such as "13335 | 1.1.1.0/24 | AU | apnic | 2011-08-11".
+// An IP covered by several announced prefixes gets a record per prefix; the most specific
+// prefix is the one traffic follows, so its origins are reported, merged across records.
+// Returns nil if there are no records.
+func parseCymruOrigin(ip string, records []string) (*CymruResponse, error) {
+	var (
+		resp    *CymruResponse
+		bestLen = -1
+	)
+	for _, record := range records {
+		fields := splitPipe(record)
+		if len(fields) < 5 {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(fields[1])
+		if err != nil {
+			continue
+		}
+
+		switch {
+		case prefix.Bits() > bestLen:
+			resp = &CymruResponse{
+				IP:          ip,
+				OriginASNs:  parseASNs(fields[0]),
+				Prefix:      fields[1],
+				CountryCode: fields[2],
+				Registry:    fields[3],
+				Allocated:   fields[4],
+			}
+			bestLen = prefix.Bits()
+		case prefix.Bits() == bestLen:
+			resp.OriginASNs = appendASNs(resp.OriginASNs, parseASNs(fields[0])...)
+		}
+	}
+
+	if resp == nil {
+		if len(records) == 0 {
+			return nil, nil
+		}
+		return nil, provider.NewParseError(fmt.Errorf("unexpected origin records: %q", records))
+	}
+	if len(resp.OriginASNs) > 0 {
+		resp.ASN = resp.OriginASNs[0]
+	}
+	return resp, nil
+}
+
+// parseCymruASName returns the AS name from records such as
+// "13335 | US | arin | 2010-07-14 | CLOUDFLARENET, US".
+func parseCymruASName(records []string) string {
+	for _, record := range records {
+		if fields := splitPipe(record); len(fields) >= 5 {
+			return fields[4]
+		}
+	}
+	return ""
+}
+
+// parseCymruPeers merges the peer ASNs of every peer zone record,
+// e.g. "1299 2914 3356 | 1.1.1.0/24 | AU | apnic | 2011-08-11".
+func parseCymruPeers(records []string) []int {
+	var peers []int
+	for _, record := range records {
+		peers = appendASNs(peers, parseASNs(splitPipe(record)[0])...)
+	}
+	return peers
+}
+
+// whois queries the bulk whois service on whois.cymru.com:43.
+// Returns nil if the IP has no origin.
+func (c *Cymru) whois(ctx context.Context, addr net.IP) (*CymruResponse, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", "whois.cymru.com:43")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	} else {
+		_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	}
+
+	if _, err := fmt.Fprintf(conn, "begin\nverbose\n%s\nend\n", addr); err != nil {
+		return nil, err
+	}
+
+	// AS | IP | BGP Prefix | CC | Registry | Allocated | AS Name
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		fields := splitPipe(scanner.Text())
+		if len(fields) < 7 || fields[0] == "AS" {
+			continue
+		}
+		if fields[0] == "NA" {
+			return nil, nil
+		}
+
+		resp := &CymruResponse{
+			IP:          fields[1],
+			OriginASNs:  parseASNs(fields[0]),
+			Prefix:      fields[2],
+			CountryCode: fields[3],
+			Registry:    fields[4],
+			Allocated:   fields[5],
+			ASName:      fields[6],
+		}
+		if len(resp.OriginASNs) > 0 {
+			resp.ASN = resp.OriginASNs[0]
+		}
+		return resp, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, provider.NewParseError(fmt.Errorf("no answer from whois.cymru.com"))
+}
+
+// splitPipe splits a Team Cymru record on "|" and trims each field.
+func splitPipe(record string) []string {
+	fields := strings.Split(record, "|")
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	return fields
+}
+
+// parseASNs parses a space-separated list of AS numbers.
+func parseASNs(s string) []int {
+	var asns []int
+	for _, f := range strings.Fields(s) {
+		if asn, err := strconv.Atoi(f); err == nil {
+			asns = append(asns, asn)
+		}
+	}
+	return asns
+}
+
+// appendASNs appends the ASNs not already in asns.
+func appendASNs(asns []int, more ...int) []int {
+	for _, asn := range more {
+		if !slices.Contains(asns, asn) {
+			asns = append(asns, asn)
+		}
+	}
+	return asns
+}
+
+func init() {
+	provider.Register(NewCymru())
+}
@@ -0,0 +1,210 @@
+package providers
+
+import (
+	"context"
+	"net"
+	"slices"
+	"testing"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// fakeTXT answers TXT lookups from fixed records. Names without records are NXDOMAIN,
+// and names in fail return a server failure.
+type fakeTXT struct {
+	records map[string][]string
+	fail    map[string]bool
+}
+
+func (f fakeTXT) LookupTXT(_ context.Context, name string) ([]string, error) {
+	if f.fail[name] {
+		return nil, &net.DNSError{Err: "server misbehaving", Name: name, IsTemporary: true}
+	}
+	records, ok := f.records[name]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return records, nil
+}
+
+func TestParseCymruOrigin(t *testing.T) {
+	tests := []struct {
+		name       string
+		records    []string
+		wantASN    int
+		wantASNs   []int
+		wantPrefix string
+		wantNil    bool
+		wantErr    bool
+	}{
+		{
+			name:       "single origin",
+			records:    []string{"13335 | 1.1.1.0/24 | AU | apnic | 2011-08-11"},
+			wantASN:    13335,
+			wantASNs:   []int{13335},
+			wantPrefix: "1.1.1.0/24",
+		},
+		{
+			name:       "multiple origins in one record",
+			records:    []string{"64500 64501 | 192.0.2.0/24 | US | arin | 1998-09-25"},
+			wantASN:    64500,
+			wantASNs:   []int{64500, 64501},
+			wantPrefix: "192.0.2.0/24",
+		},
+		{
+			name: "most specific prefix wins",
+			records: []string{
+				"3356 | 8.0.0.0/9 | US | arin | 1992-12-01",
+				"15169 | 8.8.8.0/24 | US | arin | 2023-12-28",
+			},
+			wantASN:    15169,
+			wantASNs:   []int{15169},
+			wantPrefix: "8.8.8.0/24",
+		},
+		{
+			name: "origins merged across records for the same prefix",
+			records: []string{
+				"64500 | 198.51.100.0/24 | NL | ripencc | 2001-01-01",
+				"64501 64500 | 198.51.100.0/24 | NL | ripencc | 2001-01-01",
+				"64502 | 198.51.0.0/16 | NL | ripencc | 2001-01-01",
+			},
+			wantASN:    64500,
+			wantASNs:   []int{64500, 64501},
+			wantPrefix: "198.51.100.0/24",
+		},
+		{
+			name:       "IPv6",
+			records:    []string{"15169 | 2001:4860::/32 | US | arin | 2005-03-14"},
+			wantASN:    15169,
+			wantASNs:   []int{15169},
+			wantPrefix: "2001:4860::/32",
+		},
+		{
+			name:       "malformed records skipped",
+			records:    []string{"garbage", "1 | not-a-prefix | US | arin | x", "13335 | 1.1.1.0/24 | AU | apnic | 2011-08-11"},
+			wantASN:    13335,
+			wantASNs:   []int{13335},
+			wantPrefix: "1.1.1.0/24",
+		},
+		{name: "no records", wantNil: true},
+		{name: "only malformed records", records: []string{"13335 | 1.1.1.0/24"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := parseCymruOrigin("192.0.2.1", tt.records)
+			if tt.wantErr {
+				if provider.KindOf(err) != provider.ErrorKindParse {
+					t.Errorf("err = %v, want a parse error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantNil {
+				if resp != nil {
+					t.Errorf("got %+v, want nil", resp)
+				}
+				return
+			}
+			if resp.ASN != tt.wantASN || !slices.Equal(resp.OriginASNs, tt.wantASNs) || resp.Prefix != tt.wantPrefix {
+				t.Errorf("got ASN %d origins %v prefix %q", resp.ASN, resp.OriginASNs, resp.Prefix)
+			}
+		})
+	}
+}
+
+func TestParseCymruASNameAndPeers(t *testing.T) {
+	if got := parseCymruASName([]string{"short", "13335 | US | arin | 2010-07-14 | CLOUDFLARENET, US"}); got != "CLOUDFLARENET, US" {
+		t.Errorf("AS name = %q", got)
+	}
+	if got := parseCymruASName(nil); got != "" {
+		t.Errorf("AS name = %q, want empty", got)
+	}
+
+	peers := parseCymruPeers([]string{
+		"1299 2914 | 1.1.1.0/24 | AU | apnic | 2011-08-11",
+		"2914 3356 | 1.1.0.0/16 | AU | apnic | 2011-08-11",
+		"",
+	})
+	if !slices.Equal(peers, []int{1299, 2914, 3356}) {
+		t.Errorf("peers = %v", peers)
+	}
+}
+
+func TestCymruLookup(t *testing.T) {
+	records := map[string][]string{
+		"1.1.1.1.origin.asn.cymru.com": {"13335 | 1.1.1.0/24 | AU | apnic | 2011-08-11"},
+		"AS13335.asn.cymru.com":        {"13335 | US | arin | 2010-07-14 | CLOUDFLARENET, US"},
+		"1.1.1.1.peer.asn.cymru.com":   {"1299 2914 | 1.1.1.0/24 | AU | apnic | 2011-08-11"},
+	}
+
+	tests := []struct {
+		name         string
+		ip           string
+		fail         string
+		wantPeers    []int
+		wantWarnings int
+		wantNil      bool
+		wantErr      bool
+	}{
+		{name: "origin, name and peers", ip: "1.1.1.1", wantPeers: []int{1299, 2914}},
+		{name: "peers query fails", ip: "1.1.1.1", fail: "1.1.1.1.peer.asn.cymru.com", wantWarnings: 1},
+		{name: "origin query fails", ip: "1.1.1.1", fail: "1.1.1.1.origin.asn.cymru.com", wantErr: true},
+		{name: "unannounced", ip: "192.0.2.1", wantNil: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCymru()
+			c.resolver = fakeTXT{records: records, fail: map[string]bool{tt.fail: true}}
+
+			result, err := c.Lookup(context.Background(), tt.ip)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Warnings) != tt.wantWarnings {
+				t.Errorf("warnings = %q, want %d", result.Warnings, tt.wantWarnings)
+			}
+			if tt.wantNil {
+				if result.Raw != nil {
+					t.Errorf("got %+v, want no data", result.Raw)
+				}
+				return
+			}
+
+			resp := result.Raw.(CymruResponse)
+			if resp.ASN != 13335 || resp.ASName != "CLOUDFLARENET, US" || resp.Prefix != "1.1.1.0/24" {
+				t.Errorf("origin data lost: %+v", resp)
+			}
+			if !slices.Equal(resp.Peers, tt.wantPeers) {
+				t.Errorf("peers = %v, want %v", resp.Peers, tt.wantPeers)
+			}
+		})
+	}
+}
+
+func TestCymruLookupIPv6SkipsPeers(t *testing.T) {
+	c := NewCymru()
+	c.resolver = fakeTXT{records: map[string][]string{
+		reverseIP(net.ParseIP("2606:4700::1111")) + ".origin6.asn.cymru.com": {"13335 | 2606:4700::/32 | US | arin | 2011-11-01"},
+	}}
+
+	result, err := c.Lookup(context.Background(), "2606:4700::1111")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp := result.Raw.(CymruResponse); resp.ASN != 13335 || resp.ASName != "" || resp.Peers != nil {
+		t.Errorf("got %+v", resp)
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("warnings = %q", result.Warnings)
+	}
+}
@@ -0,0 +1,47 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// newResolver returns a resolver that queries server (host:port) directly,
+// or the system resolver if server is empty.
+func newResolver(server string) *net.Resolver {
+	if server == "" {
+		return net.DefaultResolver
+	}
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// reverseIP returns the reversed DNS label form of an IP:
+// reversed octets for IPv4 ("4.3.2.1") and reversed nibbles for IPv6.
+func reverseIP(ip net.IP) string {
+	if v4 := ip.To4(); v4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d", v4[3], v4[2], v4[1], v4[0])
+	}
+
+	v6 := ip.To16()
+	const hexDigits = "0123456789abcdef"
+	labels := make([]string, 0, 32)
+	for i := len(v6) - 1; i >= 0; i-- {
+		labels = append(labels, string(hexDigits[v6[i]&0x0f]), string(hexDigits[v6[i]>>4]))
+	}
+	return strings.Join(labels, ".")
+}
+
+// isNotFound reports whether a DNS error means the name does not exist.
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
@@ -0,0 +1,245 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// DNSBLList is a DNS blocklist zone and the meaning of its return codes.
+type DNSBLList struct {
+	Zone string `json:"zone"`
+
+	// Codes maps A record answers (e.g. "127.0.0.2") to a description.
+	Codes map[string]string `json:"codes,omitempty"`
+
+	// Errors describe answers in 127.255.255.0/24, which signal a query problem rather
+	// than a listing, e.g. Spamhaus refusing queries from public resolvers.
+	Errors map[string]string `json:"errors,omitempty"`
+
+	// Policy are answers that mark a policy listing, such as an address range its ISP
+	// says should not send mail, rather than observed abuse. They don't affect the verdict.
+	Policy []string `json:"policy,omitempty"`
+}
+
+// UnmarshalJSON accepts either a bare zone name or a full list object.
+func (l *DNSBLList) UnmarshalJSON(data []byte) error {
+	var zone string
+	if err := json.Unmarshal(data, &zone); err == nil {
+		*l = DNSBLList{Zone: zone}
+		return nil
+	}
+
+	type list DNSBLList
+	return json.Unmarshal(data, (*list)(l))
+}
+
+// defaultDNSBLLists are checked when no lists are configured.
+var defaultDNSBLLists = []DNSBLList{
+	{
+		Zone: "zen.spamhaus.org",
+		Codes: map[string]string{
+			"127.0.0.2":  "SBL: Spamhaus SBL data",
+			"127.0.0.3":  "SBL: Spamhaus SBL CSS data",
+			"127.0.0.4":  "XBL: CBL data",
+			"127.0.0.9":  "SBL: Spamhaus DROP/EDROP data",
+			"127.0.0.10": "PBL: ISP maintained",
+			"127.0.0.11": "PBL: Spamhaus maintained",
+		},
+		Errors: map[string]string{
+			"127.255.255.252": "typing error in DNSBL name",
+			"127.255.255.254": "query via public/open resolver",
+			"127.255.255.255": "excessive number of queries",
+		},
+		Policy: []string{"127.0.0.10", "127.0.0.11"},
+	},
+	{Zone: "bl.spamcop.net", Codes: map[string]string{"127.0.0.2": "listed"}},
+	{Zone: "b.barracudacentral.org", Codes: map[string]string{"127.0.0.2": "listed"}},
+	{Zone: "psbl.surriel.com", Codes: map[string]string{"127.0.0.2": "listed"}},
+	{Zone: "dnsbl-1.uceprotect.net", Codes: map[string]string{"127.0.0.2": "listed"}},
+}
+
+// DNSBLSettings are the config file settings for the DNSBL provider.
+type DNSBLSettings struct {
+	// Lists replaces the default blocklists.
+	Lists []DNSBLList `json:"lists"`
+
+	// Resolver is a DNS server (host:port) to query instead of the system resolver.
+	Resolver string `json:"resolver"`
+}
+
+// DNSBLResponse is the outcome of checking an IP against every configured blocklist.
+type DNSBLResponse struct {
+	IP      string         `json:"ip"`
+	Listed  []string       `json:"listed"`
+	Checked int            `json:"checked"`
+	Results []DNSBLListing `json:"results"`
+}
+
+// DNSBLListing is the outcome for a single blocklist.
+type DNSBLListing struct {
+	Zone     string   `json:"zone"`
+	Listed   bool     `json:"listed"`
+	Codes    []string `json:"codes,omitempty"`
+	Meanings []string `json:"meanings,omitempty"`
+	Reasons  []string `json:"reasons,omitempty"`
+	Error    string   `json:"error,omitempty"`
+
+	// Policy is true if every code is a policy listing (see DNSBLList.Policy).
+	Policy bool `json:"policy,omitempty"`
+}
+
+// DNSBL implements the LookupProvider interface for DNS blocklists.
+type DNSBL struct {
+	provider.BaseProvider
+	lists []DNSBLList
+	// server is the DNS server (host:port) queried for A records; empty means the system's.
+	server   string
+	resolver *net.Resolver
+}
+
+// NewDNSBL creates a new DNSBL provider using the default blocklists.
+func NewDNSBL() *DNSBL {
+	return &DNSBL{
+		BaseProvider: provider.BaseProvider{
+			ProviderName: "DNS Blocklists",
+			ProviderID:   "dnsbl",
+		},
+		lists:    defaultDNSBLLists,
+		resolver: net.DefaultResolver,
+	}
+}
+
+// Configure applies the provider's settings.
+func (d *DNSBL) Configure(settings json.RawMessage) error {
+	var s DNSBLSettings
+	if err := json.Unmarshal(settings, &s); err != nil {
+		return err
+	}
+
+	for _, l := range s.Lists {
+		if l.Zone == "" {
+			return fmt.Errorf("blocklist entry has no zone")
+		}
+	}
+	if len(s.Lists) > 0 {
+		d.lists = s.Lists
+	}
+	d.server = s.Resolver
+	d.resolver = newResolver(s.Resolver)
+	return nil
+}
+
+// Lookup checks the IP against every blocklist concurrently.
+func (d *DNSBL) Lookup(ctx context.Context, ip string) (*provider.Result, error) {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return nil, fmt.Errorf("invalid IP address: %s", ip)
+	}
+	reversed := reverseIP(addr)
+
+	results := make([]DNSBLListing, len(d.lists))
+	var wg sync.WaitGroup
+	for i, list := range d.lists {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = d.check(ctx, reversed, list)
+		}()
+	}
+	wg.Wait()
+
+	resp := DNSBLResponse{
+		IP:      ip,
+		Listed:  []string{},
+		Checked: len(results),
+		Results: results,
+	}
+	var warnings []string
+	abuse := false
+	for _, r := range results {
+		if r.Listed {
+			resp.Listed = append(resp.Listed, r.Zone)
+			abuse = abuse || !r.Policy
+		}
+		if r.Error != "" {
+			warnings = append(warnings, r.Zone+": "+r.Error)
+		}
+	}
+
+	if len(warnings) == len(results) && len(warnings) > 0 {
+		return nil, provider.NewError(provider.ErrorKindNetwork, fmt.Errorf("all %d blocklist queries failed", len(warnings)))
+	}
+
+	result := provider.NewSuccessResult(d, 0, resp)
+	result.Warnings = warnings
+	if abuse {
+		result.Verdict = provider.VerdictSuspicious
+	}
+	return result, nil
+}
+
+// blocklistErrors is the range blocklists answer from when they refuse or reject a query.
+var blocklistErrors = netip.MustParsePrefix("127.255.255.0/24")
+
+// check queries a single blocklist for the reversed IP.
+// Only A records are queried, directly rather than through /etc/hosts, and only answers
+// in 127.0.0.0/8 count as listings; anything else usually means a resolver that rewrites
+// NXDOMAIN, and is reported as an error.
+func (d *DNSBL) check(ctx context.Context, reversed string, list DNSBLList) DNSBLListing {
+	listing := DNSBLListing{Zone: list.Zone}
+	name := reversed + "." + strings.TrimSuffix(list.Zone, ".")
+
+	addrs, err := lookupA(ctx, d.server, name)
+	if isNotFound(err) {
+		return listing
+	}
+	if err != nil {
+		listing.Error = err.Error()
+		return listing
+	}
+
+	policy := true
+	for _, addr := range addrs {
+		a := addr.String()
+		switch {
+		case blocklistErrors.Contains(addr):
+			msg, ok := list.Errors[a]
+			if !ok {
+				msg = "blocklist refused the query"
+			}
+			listing.Error = fmt.Sprintf("%s: %s", a, msg)
+			continue
+		case !addr.IsLoopback():
+			listing.Error = fmt.Sprintf("%s: unexpected answer, the resolver may rewrite NXDOMAIN", a)
+			continue
+		}
+		listing.Listed = true
+		listing.Codes = append(listing.Codes, a)
+		if meaning, ok := list.Codes[a]; ok {
+			listing.Meanings = append(listing.Meanings, meaning)
+		}
+		policy = policy && slices.Contains(list.Policy, a)
+	}
+
+	if listing.Listed {
+		listing.Policy = policy
+		// TXT reasons are optional; a failure here doesn't undo the listing.
+		if txt, err := d.resolver.LookupTXT(ctx, name); err == nil {
+			listing.Reasons = txt
+		}
+	}
+
+	return listing
+}
+
+func init() {
+	provider.Register(NewDNSBL())
+}
@@ -0,0 +1,330 @@
+package providers
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// dnsStub is a UDP DNS server answering A, AAAA, PTR and TXT queries from fixed records.
+// Addresses in a are served as A or AAAA records by family. Names without records get NXDOMAIN.
+type dnsStub struct {
+	addr string
+	a    map[string][]string
+	txt  map[string]string
+	ptr  map[string][]string
+
+	mu     sync.Mutex
+	qtypes []uint16
+}
+
+func newDNSStub(t *testing.T, a map[string][]string, txt map[string]string) *dnsStub {
+	t.Helper()
+	return serveDNS(t, &dnsStub{a: a, txt: txt})
+}
+
+// serveDNS starts answering queries from s's records and sets its address.
+func serveDNS(t *testing.T, s *dnsStub) *dnsStub {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	s.addr = conn.LocalAddr().String()
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if resp := s.answer(buf[:n]); resp != nil {
+				_, _ = conn.WriteTo(resp, from)
+			}
+		}
+	}()
+	return s
+}
+
+// answer builds the response to a single-question query.
+func (s *dnsStub) answer(query []byte) []byte {
+	if len(query) < dnsHeaderLen {
+		return nil
+	}
+	var labels []string
+	off := dnsHeaderLen
+	for off < len(query) && query[off] != 0 {
+		n := int(query[off])
+		labels = append(labels, string(query[off+1:off+1+n]))
+		off += 1 + n
+	}
+	off++
+	qtype := binary.BigEndian.Uint16(query[off:])
+	question := query[dnsHeaderLen : off+4]
+	name := strings.ToLower(strings.Join(labels, "."))
+
+	s.mu.Lock()
+	s.qtypes = append(s.qtypes, qtype)
+	s.mu.Unlock()
+
+	var rdata [][]byte
+	_, exists := s.a[name]
+	if _, ok := s.ptr[name]; ok {
+		exists = true
+	}
+	switch qtype {
+	case dnsTypeA, dnsTypeAAAA:
+		for _, a := range s.a[name] {
+			ip := netip.MustParseAddr(a)
+			if ip.Is4() == (qtype == dnsTypeA) {
+				rdata = append(rdata, ip.AsSlice())
+			}
+		}
+	case dnsTypePTR:
+		for _, target := range s.ptr[name] {
+			var rd []byte
+			for _, label := range strings.Split(strings.TrimSuffix(target, "."), ".") {
+				rd = append(append(rd, byte(len(label))), label...)
+			}
+			rdata = append(rdata, append(rd, 0))
+		}
+	case 16: // TXT
+		if txt, ok := s.txt[name]; ok {
+			rdata = append(rdata, append([]byte{byte(len(txt))}, txt...))
+		}
+	}
+
+	resp := binary.BigEndian.AppendUint16(nil, binary.BigEndian.Uint16(query))
+	rcode := uint16(0)
+	if !exists {
+		rcode = dnsRcodeNXDomain
+	}
+	resp = binary.BigEndian.AppendUint16(resp, 0x8180|rcode) // QR, RD, RA
+	resp = binary.BigEndian.AppendUint16(resp, 1)
+	resp = binary.BigEndian.AppendUint16(resp, uint16(len(rdata)))
+	resp = append(resp, 0, 0, 0, 0)
+	resp = append(resp, question...)
+	for _, rd := range rdata {
+		resp = append(resp, 0xc0, dnsHeaderLen) // pointer to the question name
+		resp = binary.BigEndian.AppendUint16(resp, qtype)
+		resp = binary.BigEndian.AppendUint16(resp, dnsClassIN)
+		resp = append(resp, 0, 0, 0, 60)
+		resp = binary.BigEndian.AppendUint16(resp, uint16(len(rd)))
+		resp = append(resp, rd...)
+	}
+	return resp
+}
+
+// newTestDNSBL returns a DNSBL checking a Spamhaus-like zone and a plain zone through server.
+func newTestDNSBL(t *testing.T, server string) *DNSBL {
+	t.Helper()
+
+	settings, err := json.Marshal(DNSBLSettings{
+		Resolver: server,
+		Lists: []DNSBLList{
+			{
+				Zone:   "zen.test",
+				Codes:  map[string]string{"127.0.0.2": "SBL", "127.0.0.10": "PBL"},
+				Errors: map[string]string{"127.255.255.254": "query via public/open resolver"},
+				Policy: []string{"127.0.0.10", "127.0.0.11"},
+			},
+			{Zone: "bl.test"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewDNSBL()
+	if err := d.Configure(settings); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestDNSBLLookup(t *testing.T) {
+	const zen, bl = "1.2.0.192.zen.test", "1.2.0.192.bl.test"
+
+	tests := []struct {
+		name        string
+		a           map[string][]string
+		wantListed  []string
+		wantVerdict provider.Verdict
+		wantPolicy  bool
+		wantError   string
+	}{
+		{name: "not listed"},
+		{
+			name:        "abuse listing",
+			a:           map[string][]string{zen: {"127.0.0.2"}},
+			wantListed:  []string{"zen.test"},
+			wantVerdict: provider.VerdictSuspicious,
+		},
+		{
+			name:       "policy listing only",
+			a:          map[string][]string{zen: {"127.0.0.10", "127.0.0.11"}},
+			wantListed: []string{"zen.test"},
+			wantPolicy: true,
+		},
+		{
+			name:        "policy and abuse listing",
+			a:           map[string][]string{zen: {"127.0.0.10", "127.0.0.2"}},
+			wantListed:  []string{"zen.test"},
+			wantVerdict: provider.VerdictSuspicious,
+		},
+		{
+			name:        "policy listing with abuse elsewhere",
+			a:           map[string][]string{zen: {"127.0.0.10"}, bl: {"127.0.0.2"}},
+			wantListed:  []string{"zen.test", "bl.test"},
+			wantVerdict: provider.VerdictSuspicious,
+			wantPolicy:  true,
+		},
+		{
+			name:      "known error code",
+			a:         map[string][]string{zen: {"127.255.255.254"}},
+			wantError: "127.255.255.254: query via public/open resolver",
+		},
+		{
+			name:      "unknown error code",
+			a:         map[string][]string{zen: {"127.255.255.1"}},
+			wantError: "127.255.255.1: blocklist refused the query",
+		},
+		{
+			name:      "rewritten NXDOMAIN",
+			a:         map[string][]string{zen: {"192.0.2.53"}},
+			wantError: "192.0.2.53: unexpected answer, the resolver may rewrite NXDOMAIN",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newDNSStub(t, tt.a, map[string]string{zen: "listed for testing"})
+			result, err := newTestDNSBL(t, stub.addr).Lookup(context.Background(), "192.0.2.1")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp := result.Raw.(DNSBLResponse)
+			if !slices.Equal(resp.Listed, tt.wantListed) {
+				t.Errorf("listed = %v, want %v", resp.Listed, tt.wantListed)
+			}
+			if result.Verdict != tt.wantVerdict {
+				t.Errorf("verdict = %q, want %q", result.Verdict, tt.wantVerdict)
+			}
+
+			zenResult := resp.Results[0]
+			if zenResult.Policy != tt.wantPolicy {
+				t.Errorf("policy = %v, want %v", zenResult.Policy, tt.wantPolicy)
+			}
+			if zenResult.Error != tt.wantError {
+				t.Errorf("error = %q, want %q", zenResult.Error, tt.wantError)
+			}
+			var wantWarnings []string
+			if tt.wantError != "" {
+				wantWarnings = []string{"zen.test: " + tt.wantError}
+			}
+			if !slices.Equal(result.Warnings, wantWarnings) {
+				t.Errorf("warnings = %v, want %v", result.Warnings, wantWarnings)
+			}
+			if zenResult.Listed && !slices.Equal(zenResult.Reasons, []string{"listed for testing"}) {
+				t.Errorf("reasons = %v", zenResult.Reasons)
+			}
+
+			stub.mu.Lock()
+			defer stub.mu.Unlock()
+			for _, qtype := range stub.qtypes {
+				if qtype != dnsTypeA && qtype != 16 {
+					t.Errorf("unexpected query type %d", qtype)
+				}
+			}
+		})
+	}
+}
+
+func TestDNSBLLookupAllFailed(t *testing.T) {
+	stub := newDNSStub(t, map[string][]string{
+		"1.2.0.192.zen.test": {"127.255.255.255"},
+		"1.2.0.192.bl.test":  {"10.0.0.1"},
+	}, nil)
+
+	_, err := newTestDNSBL(t, stub.addr).Lookup(context.Background(), "192.0.2.1")
+	if provider.KindOf(err) != provider.ErrorKindNetwork {
+		t.Errorf("got %v (kind %q), want a network error", err, provider.KindOf(err))
+	}
+}
+
+func TestParseResponse(t *testing.T) {
+	stub := &dnsStub{a: map[string][]string{"listed.test": {"127.0.0.2", "127.0.0.4"}}}
+	query, id, err := buildQuery("listed.test", dnsTypeA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := stub.answer(query)
+
+	answers, rcode, err := parseResponse(resp, id, dnsTypeA)
+	addrs := answerAddrs(answers)
+	if err != nil || rcode != 0 || len(addrs) != 2 || addrs[1].String() != "127.0.0.4" {
+		t.Errorf("got %v rcode %d err %v", addrs, rcode, err)
+	}
+	if _, _, err := parseResponse(resp, id+1, dnsTypeA); err == nil {
+		t.Error("response with the wrong ID was accepted")
+	}
+	for n := range len(resp) {
+		if _, _, err := parseResponse(resp[:n], id, dnsTypeA); err == nil {
+			t.Errorf("response truncated to %d bytes was accepted", n)
+		}
+	}
+}
+
+func TestSystemNameserver(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantOK  bool
+	}{
+		{name: "first nameserver", content: "search example.com\nnameserver bogus\nnameserver 192.0.2.53\nnameserver 192.0.2.54\n", want: "192.0.2.53:53", wantOK: true},
+		{name: "ipv6 nameserver", content: "nameserver 2001:db8::53\n", want: "[2001:db8::53]:53", wantOK: true},
+		{name: "no nameservers", content: "options ndots:1\n", want: "127.0.0.1:53", wantOK: true},
+		{name: "missing file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, strings.ReplaceAll(tt.name, " ", "-"))
+			if tt.content != "" {
+				if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got, ok := systemNameserver(path); got != tt.want || ok != tt.wantOK {
+				t.Errorf("got %q %v, want %q %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestLookupAWithoutResolvConf(t *testing.T) {
+	saved := resolvConfPath
+	t.Cleanup(func() { resolvConfPath = saved })
+	resolvConfPath = filepath.Join(t.TempDir(), "resolv.conf")
+
+	// Without resolv.conf the platform resolver answers, which knows localhost.
+	addrs, err := lookupA(context.Background(), "", "localhost")
+	if err != nil || !slices.Contains(addrs, netip.MustParseAddr("127.0.0.1")) {
+		t.Errorf("got %v, %v; want 127.0.0.1 from the platform resolver", addrs, err)
+	}
+}
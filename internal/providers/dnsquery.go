@@ -0,0 +1,379 @@
+package providers
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"time"
+)
+
+// DNS wire format constants used by the direct queries.
+const (
+	dnsTypeA         = 1
+	dnsTypePTR       = 12
+	dnsTypeAAAA      = 28
+	dnsClassIN       = 1
+	dnsRcodeNXDomain = 3
+	dnsHeaderLen     = 12
+	dnsMaxUDPSize    = 4096
+	dnsQueryTimeout  = 5 * time.Second
+)
+
+// errDNSMalformed is returned when a DNS response cannot be decoded.
+var errDNSMalformed = errors.New("malformed DNS response")
+
+// resolvConfPath is where the system nameservers are read from.
+var resolvConfPath = "/etc/resolv.conf"
+
+// dnsAnswer is a single answer record: an address for A and AAAA, a name for PTR.
+type dnsAnswer struct {
+	addr netip.Addr
+	name string
+}
+
+// lookupA sends an A query for name straight to server (host:port), or to the first
+// nameserver in resolv.conf if server is empty. Unlike net.Resolver it never
+// consults /etc/hosts and never returns AAAA records. A name that does not exist,
+// or has no A records, is reported as a *net.DNSError with IsNotFound set.
+//
+// Where there is no resolv.conf (e.g. on Windows) and no server is given, the
+// query goes through net.DefaultResolver, which may also answer from the hosts file.
+func lookupA(ctx context.Context, server, name string) ([]netip.Addr, error) {
+	server, ok := nameserver(server)
+	if !ok {
+		return lookupASystem(ctx, name)
+	}
+
+	answers, err := queryDNS(ctx, server, name, dnsTypeA)
+	if err != nil {
+		return nil, err
+	}
+	return answerAddrs(answers), nil
+}
+
+// lookupIP returns name's A and AAAA records, queried like lookupA. It fails only
+// if neither query returned an address.
+func lookupIP(ctx context.Context, server, name string) ([]net.IPAddr, error) {
+	server, ok := nameserver(server)
+	if !ok {
+		return net.DefaultResolver.LookupIPAddr(ctx, name)
+	}
+
+	var (
+		addrs    []net.IPAddr
+		firstErr error
+	)
+	for _, qtype := range []uint16{dnsTypeA, dnsTypeAAAA} {
+		answers, err := queryDNS(ctx, server, name, qtype)
+		if err != nil && (firstErr == nil || isNotFound(firstErr)) {
+			firstErr = err
+		}
+		for _, a := range answerAddrs(answers) {
+			addrs = append(addrs, net.IPAddr{IP: a.AsSlice()})
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, firstErr
+	}
+	return addrs, nil
+}
+
+// lookupPTR returns the PTR names (with a trailing dot) for ip, queried like lookupA.
+func lookupPTR(ctx context.Context, server string, ip net.IP) ([]string, error) {
+	server, ok := nameserver(server)
+	if !ok {
+		return net.DefaultResolver.LookupAddr(ctx, ip.String())
+	}
+
+	zone := ".in-addr.arpa"
+	if ip.To4() == nil {
+		zone = ".ip6.arpa"
+	}
+	answers, err := queryDNS(ctx, server, reverseIP(ip)+zone, dnsTypePTR)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(answers))
+	for _, a := range answers {
+		names = append(names, a.name)
+	}
+	return names, nil
+}
+
+// lookupASystem resolves name's A records with the platform resolver.
+func lookupASystem(ctx context.Context, name string) ([]netip.Addr, error) {
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip4", name)
+	if err != nil {
+		return nil, err
+	}
+	for i, a := range addrs {
+		addrs[i] = a.Unmap()
+	}
+	return addrs, nil
+}
+
+// nameserver returns server, or the resolv.conf nameserver if server is empty.
+// It reports false if neither is available and the platform resolver must be used.
+func nameserver(server string) (string, bool) {
+	if server != "" {
+		return server, true
+	}
+	return systemNameserver(resolvConfPath)
+}
+
+// queryDNS sends a qtype query for name to server and returns the matching answers.
+// A name that does not exist, or has no records of the type, is reported as a
+// *net.DNSError with IsNotFound set.
+func queryDNS(ctx context.Context, server, name string, qtype uint16) ([]dnsAnswer, error) {
+	query, id, err := buildQuery(name, qtype)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := exchangeDNS(ctx, "udp", server, query)
+	if err == nil && len(resp) > 2 && resp[2]&0x02 != 0 {
+		// Truncated: retry over TCP for the full answer.
+		resp, err = exchangeDNS(ctx, "tcp", server, query)
+	}
+	if err != nil {
+		return nil, &net.DNSError{Err: err.Error(), Name: name, Server: server, IsTimeout: isTimeout(err)}
+	}
+
+	answers, rcode, err := parseResponse(resp, id, qtype)
+	if err != nil {
+		return nil, &net.DNSError{Err: err.Error(), Name: name, Server: server}
+	}
+	switch {
+	case rcode == dnsRcodeNXDomain, rcode == 0 && len(answers) == 0:
+		return nil, &net.DNSError{Err: "no such host", Name: name, Server: server, IsNotFound: true}
+	case rcode != 0:
+		return nil, &net.DNSError{Err: fmt.Sprintf("server returned rcode %d", rcode), Name: name, Server: server}
+	}
+	return answers, nil
+}
+
+// answerAddrs returns the addresses among answers.
+func answerAddrs(answers []dnsAnswer) []netip.Addr {
+	var addrs []netip.Addr
+	for _, a := range answers {
+		if a.addr.IsValid() {
+			addrs = append(addrs, a.addr)
+		}
+	}
+	return addrs
+}
+
+// buildQuery encodes a recursive qtype query for name and returns it with its ID.
+func buildQuery(name string, qtype uint16) ([]byte, uint16, error) {
+	id := uint16(rand.Uint32())
+	msg := make([]byte, dnsHeaderLen, dnsHeaderLen+len(name)+6)
+	binary.BigEndian.PutUint16(msg[0:], id)
+	binary.BigEndian.PutUint16(msg[2:], 0x0100) // RD
+	binary.BigEndian.PutUint16(msg[4:], 1)      // QDCOUNT
+
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if label == "" || len(label) > 63 {
+			return nil, 0, fmt.Errorf("invalid DNS name %q", name)
+		}
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	msg = append(msg, 0)
+	msg = binary.BigEndian.AppendUint16(msg, qtype)
+	msg = binary.BigEndian.AppendUint16(msg, dnsClassIN)
+	return msg, id, nil
+}
+
+// exchangeDNS sends query to server over network ("udp" or "tcp") and returns the raw response.
+func exchangeDNS(ctx context.Context, network, server string, query []byte) ([]byte, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, server)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	deadline := time.Now().Add(dnsQueryTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	if network == "udp" {
+		if _, err := conn.Write(query); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, dnsMaxUDPSize)
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+
+	// TCP messages carry a two-byte length prefix.
+	if _, err := conn.Write(binary.BigEndian.AppendUint16(nil, uint16(len(query)))); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+	var length [2]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return nil, err
+	}
+	resp := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// parseResponse returns the qtype answers and rcode from a response to the query with the given ID.
+func parseResponse(msg []byte, id, qtype uint16) ([]dnsAnswer, int, error) {
+	if len(msg) < dnsHeaderLen {
+		return nil, 0, errDNSMalformed
+	}
+	if binary.BigEndian.Uint16(msg[0:]) != id || msg[2]&0x80 == 0 {
+		return nil, 0, errors.New("DNS response does not match the query")
+	}
+	rcode := int(msg[3] & 0x0f)
+	qdcount := int(binary.BigEndian.Uint16(msg[4:]))
+	ancount := int(binary.BigEndian.Uint16(msg[6:]))
+
+	off := dnsHeaderLen
+	for range qdcount {
+		var ok bool
+		if off, ok = skipDNSName(msg, off); !ok || off+4 > len(msg) {
+			return nil, 0, errDNSMalformed
+		}
+		off += 4
+	}
+
+	var answers []dnsAnswer
+	for range ancount {
+		var ok bool
+		if off, ok = skipDNSName(msg, off); !ok || off+10 > len(msg) {
+			return nil, 0, errDNSMalformed
+		}
+		rtype := binary.BigEndian.Uint16(msg[off:])
+		class := binary.BigEndian.Uint16(msg[off+2:])
+		rdlen := int(binary.BigEndian.Uint16(msg[off+8:]))
+		off += 10
+		if off+rdlen > len(msg) {
+			return nil, 0, errDNSMalformed
+		}
+		// CNAMEs in the chain are skipped; only the final records matter.
+		if rtype == qtype && class == dnsClassIN {
+			rdata := msg[off : off+rdlen]
+			switch {
+			case rtype == dnsTypeA && rdlen == 4:
+				answers = append(answers, dnsAnswer{addr: netip.AddrFrom4([4]byte(rdata))})
+			case rtype == dnsTypeAAAA && rdlen == 16:
+				answers = append(answers, dnsAnswer{addr: netip.AddrFrom16([16]byte(rdata))})
+			case rtype == dnsTypePTR:
+				name, ok := readDNSName(msg, off)
+				if !ok {
+					return nil, 0, errDNSMalformed
+				}
+				answers = append(answers, dnsAnswer{name: name})
+			}
+		}
+		off += rdlen
+	}
+	return answers, rcode, nil
+}
+
+// readDNSName decodes the (possibly compressed) name at off, with a trailing dot.
+func readDNSName(msg []byte, off int) (string, bool) {
+	var b strings.Builder
+	// Each pointer must jump backwards, so a loop of pointers cannot go on forever.
+	limit := len(msg)
+	for off < limit {
+		length := int(msg[off])
+		switch {
+		case length == 0:
+			if b.Len() == 0 {
+				return ".", true
+			}
+			return b.String(), true
+		case length&0xc0 == 0xc0:
+			if off+2 > len(msg) {
+				return "", false
+			}
+			limit = off
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3fff)
+			continue
+		case length&0xc0 != 0:
+			return "", false
+		}
+		if off+1+length > len(msg) {
+			return "", false
+		}
+		b.Write(msg[off+1 : off+1+length])
+		b.WriteByte('.')
+		off += 1 + length
+	}
+	return "", false
+}
+
+// skipDNSName returns the offset just past the (possibly compressed) name at off.
+func skipDNSName(msg []byte, off int) (int, bool) {
+	for off < len(msg) {
+		length := int(msg[off])
+		switch {
+		case length == 0:
+			return off + 1, true
+		case length&0xc0 == 0xc0:
+			// A compression pointer ends the name.
+			return off + 2, off+2 <= len(msg)
+		case length&0xc0 != 0:
+			return 0, false
+		}
+		off += 1 + length
+	}
+	return 0, false
+}
+
+// systemNameserver returns the first nameserver in the resolv.conf at path as host:port.
+// It reports false if the file does not exist. A file without nameservers means the
+// local resolver, as in the C library.
+func systemNameserver(path string) (string, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", false
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			if addr, err := netip.ParseAddr(fields[1]); err == nil {
+				return netip.AddrPortFrom(addr, 53).String(), true
+			}
+		}
+	}
+	return "127.0.0.1:53", true
+}
+
+// isTimeout reports whether err is a network timeout.
+func isTimeout(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
@@ -0,0 +1,50 @@
+package providers
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// fileCache holds a value loaded from a local file and reloads it
+// whenever the file's size or modification time changes.
+type fileCache[T any] struct {
+	path string
+	load func(path string) (T, error)
+
+	mu      sync.Mutex
+	loaded  bool
+	modTime time.Time
+	size    int64
+	value   T
+}
+
+// newFileCache creates a cache for path using load to parse it.
+func newFileCache[T any](path string, load func(path string) (T, error)) *fileCache[T] {
+	return &fileCache[T]{path: path, load: load}
+}
+
+// get returns the cached value, reloading the file first if it has changed.
+func (c *fileCache[T]) get() (T, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	info, err := os.Stat(c.path)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	if c.loaded && info.ModTime().Equal(c.modTime) && info.Size() == c.size {
+		return c.value, nil
+	}
+
+	value, err := c.load(c.path)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	c.value, c.modTime, c.size, c.loaded = value, info.ModTime(), info.Size(), true
+	return value, nil
+}
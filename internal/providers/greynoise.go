@@ -2,7 +2,6 @@ package providers
 
 import (
 	"encoding/json"
-	"fmt"
 
 	"github.com/dalryan/ip-enrich/internal/provider"
 )
@@ -43,15 +42,21 @@ func (g *GreyNoise) ParseResponse(body []byte, statusCode int) (*provider.Result
 	}
 
 	if statusCode != 200 {
-		return provider.NewErrorResult(g, statusCode, fmt.Errorf("unexpected status code: %d", statusCode)), nil
+		return provider.NewErrorResult(g, statusCode, provider.NewStatusError(statusCode)), nil
 	}
 
 	var resp GreyNoiseResponse
 	if err := json.Unmarshal(body, &resp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		return nil, provider.NewParseError(err)
 	}
 
 	result := provider.NewSuccessResult(g, statusCode, resp)
+	switch {
+	case resp.Classification == "malicious":
+		result.Verdict = provider.VerdictMalicious
+	case resp.Classification == "benign" || resp.Riot:
+		result.Verdict = provider.VerdictBenign
+	}
 
 	return result, nil
 }
@@ -0,0 +1,45 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// redirectTransport sends every request to a test server, keeping the path and query.
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (r redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = r.target.Scheme
+	req.URL.Host = r.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// runAgainst runs p for ip with all of its HTTP calls answered by handler.
+func runAgainst(t *testing.T, p provider.Provider, ip string, handler http.Handler) *provider.Result {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	target, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := provider.NewExecutor(
+		provider.WithHTTPClient(&http.Client{Transport: redirectTransport{target: target}}),
+		provider.WithCircuitBreaker(0, 0),
+	)
+	results := e.Execute(context.Background(), ip, []provider.Provider{p}, nil)
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	return results[0]
+}
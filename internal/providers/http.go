@@ -0,0 +1,36 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// getJSON fetches url for a lookup provider and decodes a 200 response into v.
+// Any other status is returned as a classified status error alongside the status code.
+func getJSON(ctx context.Context, url string, headers map[string]string, v any) (int, error) {
+	req, err := provider.NewRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	for k, val := range headers {
+		req.Header.Set(k, val)
+	}
+
+	resp, err := provider.Fetch(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, provider.NewStatusError(resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(resp.Body, v); err != nil {
+		return resp.StatusCode, provider.NewParseError(err)
+	}
+
+	return resp.StatusCode, nil
+}
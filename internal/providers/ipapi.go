@@ -2,7 +2,6 @@ package providers
 
 import (
 	"encoding/json"
-	"fmt"
 
 	"github.com/dalryan/ip-enrich/internal/provider"
 )
@@ -65,15 +64,18 @@ func NewIPAPI() *IPAPI {
 // ParseResponse parses the ipapi.is API response into normalized fields.
 func (i *IPAPI) ParseResponse(body []byte, statusCode int) (*provider.Result, error) {
 	if statusCode != 200 {
-		return provider.NewErrorResult(i, statusCode, fmt.Errorf("unexpected status code: %d", statusCode)), nil
+		return provider.NewErrorResult(i, statusCode, provider.NewStatusError(statusCode)), nil
 	}
 
 	var resp IPAPIResponse
 	if err := json.Unmarshal(body, &resp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		return nil, provider.NewParseError(err)
 	}
 
 	result := provider.NewSuccessResult(i, statusCode, resp)
+	if resp.IsAbuser {
+		result.Verdict = provider.VerdictSuspicious
+	}
 	return result, nil
 }
 
@@ -0,0 +1,114 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// IPInfoResponse represents the response from ipinfo.io.
+// The free tier returns only the top-level fields; ASN, Company, Carrier and Privacy
+// are filled in when the token's plan includes them.
+type IPInfoResponse struct {
+	IP       string `json:"ip"`
+	Hostname string `json:"hostname,omitempty"`
+	Anycast  bool   `json:"anycast,omitempty"`
+	Bogon    bool   `json:"bogon,omitempty"`
+	City     string `json:"city,omitempty"`
+	Region   string `json:"region,omitempty"`
+	Country  string `json:"country,omitempty"`
+	Loc      string `json:"loc,omitempty"`
+	Postal   string `json:"postal,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+	// Org is "AS<number> <name>" on the free tier.
+	Org string `json:"org,omitempty"`
+	ASN *struct {
+		ASN    string `json:"asn"`
+		Name   string `json:"name"`
+		Domain string `json:"domain"`
+		Route  string `json:"route"`
+		Type   string `json:"type"`
+	} `json:"asn,omitempty"`
+	Company *struct {
+		Name   string `json:"name"`
+		Domain string `json:"domain"`
+		Type   string `json:"type"`
+	} `json:"company,omitempty"`
+	Carrier *struct {
+		Name string `json:"name"`
+		MCC  string `json:"mcc"`
+		MNC  string `json:"mnc"`
+	} `json:"carrier,omitempty"`
+	Privacy *struct {
+		VPN     bool   `json:"vpn"`
+		Proxy   bool   `json:"proxy"`
+		Tor     bool   `json:"tor"`
+		Relay   bool   `json:"relay"`
+		Hosting bool   `json:"hosting"`
+		Service string `json:"service"`
+	} `json:"privacy,omitempty"`
+}
+
+// ipinfoError is the error body ipinfo.io returns with non-2xx statuses.
+type ipinfoError struct {
+	Error struct {
+		Title   string `json:"title"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// IPInfo implements the Provider interface for ipinfo.io.
+type IPInfo struct {
+	provider.BaseProvider
+}
+
+// NewIPInfo creates a new ipinfo.io provider. It uses the free tier until a token is configured.
+func NewIPInfo() *IPInfo {
+	return &IPInfo{
+		BaseProvider: provider.BaseProvider{
+			ProviderName: "ipinfo.io",
+			ProviderID:   "ipinfo",
+			URLTemplate:  "https://ipinfo.io/{ip}/json",
+		},
+	}
+}
+
+// Configure applies the provider's settings.
+func (i *IPInfo) Configure(settings json.RawMessage) error {
+	var s provider.KeySettings
+	if err := json.Unmarshal(settings, &s); err != nil {
+		return err
+	}
+
+	// The token is optional, so only require auth once one is set.
+	i.Auth = provider.Auth{}
+	if key := s.Key(); key != "" {
+		i.Auth = provider.Auth{Scheme: provider.AuthBearer, Key: key}
+	}
+	return nil
+}
+
+// ParseResponse parses the ipinfo.io response.
+func (i *IPInfo) ParseResponse(body []byte, statusCode int) (*provider.Result, error) {
+	if statusCode != http.StatusOK {
+		err := provider.NewStatusError(statusCode)
+		var apiErr ipinfoError
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Error.Message != "" {
+			err.Err = fmt.Errorf("%w: %s", err.Err, apiErr.Error.Message)
+		}
+		return provider.NewErrorResult(i, statusCode, err), nil
+	}
+
+	var resp IPInfoResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, provider.NewParseError(err)
+	}
+
+	return provider.NewSuccessResult(i, statusCode, resp), nil
+}
+
+func init() {
+	provider.Register(NewIPInfo())
+}
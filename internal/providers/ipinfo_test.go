@@ -0,0 +1,111 @@
+package providers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+func TestIPInfoParseResponse(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantKind    provider.ErrorKind
+		wantError   string
+		wantPrivacy bool
+		wantBogon   bool
+	}{
+		{
+			name:   "free tier",
+			status: 200,
+			body:   `{"ip":"8.8.8.8","hostname":"dns.google","anycast":true,"city":"Mountain View","country":"US","org":"AS15169 Google LLC"}`,
+		},
+		{
+			name:   "privacy detection",
+			status: 200,
+			body: `{"ip":"192.0.2.1","asn":{"asn":"AS64500","name":"Example VPN","type":"hosting"},
+				"privacy":{"vpn":true,"proxy":false,"tor":false,"relay":false,"hosting":true,"service":"ExampleVPN"}}`,
+			wantPrivacy: true,
+		},
+		{name: "bogon", status: 200, body: `{"ip":"10.0.0.1","bogon":true}`, wantBogon: true},
+		{
+			name:      "invalid token",
+			status:    403,
+			body:      `{"status":403,"error":{"title":"Unknown token","message":"Please ensure you've entered your token correctly."}}`,
+			wantKind:  provider.ErrorKindAuth,
+			wantError: "Please ensure you've entered your token correctly.",
+		},
+		{
+			name:      "wrong ip",
+			status:    404,
+			body:      `{"status":404,"error":{"title":"Wrong ip","message":"Please provide a valid IP address"}}`,
+			wantKind:  provider.ErrorKindNotFound,
+			wantError: "Please provide a valid IP address",
+		},
+		{name: "rate limited", status: 429, body: `Rate limit exceeded.`, wantKind: provider.ErrorKindRateLimited},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewIPInfo().ParseResponse([]byte(tt.body), tt.status)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.ErrorKind != tt.wantKind {
+				t.Fatalf("ErrorKind = %q, want %q (%s)", result.ErrorKind, tt.wantKind, result.Error)
+			}
+			if !strings.Contains(result.Error, tt.wantError) {
+				t.Errorf("Error = %q, want it to contain %q", result.Error, tt.wantError)
+			}
+			// Privacy flags describe the network, not the IP's behaviour.
+			if result.Verdict != "" {
+				t.Errorf("Verdict = %q, want none", result.Verdict)
+			}
+			if tt.wantKind != "" {
+				return
+			}
+
+			resp := result.Raw.(IPInfoResponse)
+			if (resp.Privacy != nil && resp.Privacy.VPN) != tt.wantPrivacy || resp.Bogon != tt.wantBogon {
+				t.Errorf("privacy %+v bogon %v, want VPN %v bogon %v", resp.Privacy, resp.Bogon, tt.wantPrivacy, tt.wantBogon)
+			}
+		})
+	}
+}
+
+func TestIPInfoToken(t *testing.T) {
+	tests := []struct {
+		name       string
+		settings   string
+		wantHeader string
+	}{
+		{name: "free tier", settings: `{}`},
+		{name: "token", settings: `{"api_key":"secret"}`, wantHeader: "Bearer secret"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewIPInfo()
+			if err := p.Configure([]byte(tt.settings)); err != nil {
+				t.Fatal(err)
+			}
+
+			result := runAgainst(t, p, "8.8.8.8", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("Authorization"); got != tt.wantHeader {
+					t.Errorf("Authorization = %q, want %q", got, tt.wantHeader)
+				}
+				if r.URL.Path != "/8.8.8.8/json" {
+					t.Errorf("path = %q, want /8.8.8.8/json", r.URL.Path)
+				}
+				fmt.Fprint(w, `{"ip":"8.8.8.8"}`)
+			}))
+			if result.Error != "" {
+				t.Errorf("lookup failed: %s", result.Error)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package providers
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/dalryan/ip-enrich/internal/provider"
 )
@@ -11,6 +12,7 @@ import (
 type IPWhoisResponse struct {
 	IP            string  `json:"ip"`
 	Success       bool    `json:"success"`
+	Message       string  `json:"message,omitempty"`
 	Type          string  `json:"type"`
 	Continent     string  `json:"continent"`
 	ContinentCode string  `json:"continent_code"`
@@ -66,16 +68,16 @@ func NewIPWhois() *IPWhois {
 // ParseResponse parses the ipwho.is API response into normalized fields.
 func (i *IPWhois) ParseResponse(body []byte, statusCode int) (*provider.Result, error) {
 	if statusCode != 200 {
-		return provider.NewErrorResult(i, statusCode, fmt.Errorf("unexpected status code: %d", statusCode)), nil
+		return provider.NewErrorResult(i, statusCode, provider.NewStatusError(statusCode)), nil
 	}
 
 	var resp IPWhoisResponse
 	if err := json.Unmarshal(body, &resp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		return nil, provider.NewParseError(err)
 	}
 
 	if !resp.Success {
-		return provider.NewErrorResult(i, statusCode, fmt.Errorf("API returned success=false")), nil
+		return provider.NewErrorResult(i, statusCode, ipwhoisError(resp.Message)), nil
 	}
 
 	result := provider.NewSuccessResult(i, statusCode, resp)
@@ -83,6 +85,16 @@ func (i *IPWhois) ParseResponse(body []byte, statusCode int) (*provider.Result,
 	return result, nil
 }
 
+// ipwhoisError classifies the message ipwho.is sends with success=false.
+// It reports the free tier's monthly limit in the body, with a 200 status.
+func ipwhoisError(message string) error {
+	kind := provider.ErrorKindAPI
+	if strings.Contains(strings.ToLower(message), "limit") {
+		kind = provider.ErrorKindQuota
+	}
+	return provider.NewError(kind, fmt.Errorf("API returned success=false: %s", message))
+}
+
 func init() {
 	provider.Register(NewIPWhois())
 }
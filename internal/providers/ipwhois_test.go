@@ -0,0 +1,39 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+func TestIPWhoisParseResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantKind provider.ErrorKind
+		wantASN  int
+	}{
+		{name: "success", body: `{"ip":"8.8.8.8","success":true,"connection":{"asn":15169}}`, wantASN: 15169},
+		{name: "reserved range", body: `{"ip":"10.0.0.1","success":false,"message":"Reserved range"}`, wantKind: provider.ErrorKindAPI},
+		{name: "monthly limit", body: `{"success":false,"message":"You've hit the monthly limit"}`, wantKind: provider.ErrorKindQuota},
+		{name: "malformed", body: `{"success":`, wantKind: provider.ErrorKindParse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewIPWhois().ParseResponse([]byte(tt.body), 200)
+			if err != nil {
+				if got := provider.KindOf(err); got != tt.wantKind {
+					t.Fatalf("error kind = %q, want %q (%v)", got, tt.wantKind, err)
+				}
+				return
+			}
+			if result.ErrorKind != tt.wantKind {
+				t.Fatalf("ErrorKind = %q, want %q (%s)", result.ErrorKind, tt.wantKind, result.Error)
+			}
+			if tt.wantKind == "" && result.Raw.(IPWhoisResponse).Connection.ASN != tt.wantASN {
+				t.Errorf("ASN = %d, want %d", result.Raw.(IPWhoisResponse).Connection.ASN, tt.wantASN)
+			}
+		})
+	}
+}
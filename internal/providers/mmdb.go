@@ -0,0 +1,197 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/dalryan/ip-enrich/internal/mmdb"
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// MMDBResponse is the geolocation and ASN data found in local MaxMind DB files.
+type MMDBResponse struct {
+	IP             string            `json:"ip"`
+	Continent      string            `json:"continent,omitempty"`
+	CountryCode    string            `json:"country_code,omitempty"`
+	Country        string            `json:"country,omitempty"`
+	Region         string            `json:"region,omitempty"`
+	City           string            `json:"city,omitempty"`
+	PostalCode     string            `json:"postal_code,omitempty"`
+	Latitude       float64           `json:"latitude,omitempty"`
+	Longitude      float64           `json:"longitude,omitempty"`
+	AccuracyRadius int               `json:"accuracy_radius,omitempty"`
+	TimeZone       string            `json:"time_zone,omitempty"`
+	ASN            int               `json:"asn,omitempty"`
+	ASOrg          string            `json:"as_org,omitempty"`
+	Networks       map[string]string `json:"networks"`
+}
+
+// mmdbGeoRecord is the City/Country record layout shared by GeoLite2 and DB-IP.
+type mmdbGeoRecord struct {
+	Continent struct {
+		Code string `json:"code"`
+	} `json:"continent"`
+	Country struct {
+		ISOCode string            `json:"iso_code"`
+		Names   map[string]string `json:"names"`
+	} `json:"country"`
+	Subdivisions []struct {
+		Names map[string]string `json:"names"`
+	} `json:"subdivisions"`
+	City struct {
+		Names map[string]string `json:"names"`
+	} `json:"city"`
+	Postal struct {
+		Code string `json:"code"`
+	} `json:"postal"`
+	Location struct {
+		Latitude       float64 `json:"latitude"`
+		Longitude      float64 `json:"longitude"`
+		AccuracyRadius int     `json:"accuracy_radius"`
+		TimeZone       string  `json:"time_zone"`
+	} `json:"location"`
+}
+
+// mmdbASNRecord is the ASN record layout shared by GeoLite2 and DB-IP.
+type mmdbASNRecord struct {
+	Number       int    `json:"autonomous_system_number"`
+	Organization string `json:"autonomous_system_organization"`
+}
+
+// MMDBSettings are the config file settings for the MMDB provider.
+type MMDBSettings struct {
+	// City, Country and ASN are paths to .mmdb files, relative to the data directory
+	// unless absolute. Any subset may be set; Country is only consulted when City is not set.
+	City    string `json:"city"`
+	Country string `json:"country"`
+	ASN     string `json:"asn"`
+}
+
+// MMDB implements the LookupProvider interface for local MaxMind DB files.
+type MMDB struct {
+	provider.BaseProvider
+	geo *fileCache[*mmdb.Reader]
+	asn *fileCache[*mmdb.Reader]
+}
+
+// NewMMDB creates a new MMDB provider. It is disabled until a database path is configured.
+func NewMMDB() *MMDB {
+	return &MMDB{
+		BaseProvider: provider.BaseProvider{
+			ProviderName: "MaxMind DB (local)",
+			ProviderID:   "mmdb",
+		},
+	}
+}
+
+// Configure applies the provider's settings.
+func (m *MMDB) Configure(settings json.RawMessage) error {
+	var s MMDBSettings
+	if err := json.Unmarshal(settings, &s); err != nil {
+		return err
+	}
+
+	geoPath := s.City
+	if geoPath == "" {
+		geoPath = s.Country
+	}
+
+	m.geo, m.asn = nil, nil
+	if geoPath != "" {
+		m.geo = newFileCache(provider.DataPath(geoPath), mmdb.Open)
+	}
+	if s.ASN != "" {
+		m.asn = newFileCache(provider.DataPath(s.ASN), mmdb.Open)
+	}
+	return nil
+}
+
+// Enabled reports whether at least one database is configured.
+func (m *MMDB) Enabled() bool {
+	return m.geo != nil || m.asn != nil
+}
+
+// Lookup answers from the configured databases without any network calls.
+func (m *MMDB) Lookup(_ context.Context, ip string) (*provider.Result, error) {
+	if !m.Enabled() {
+		return nil, errors.New("no MMDB databases configured")
+	}
+
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return nil, fmt.Errorf("invalid IP address: %s", ip)
+	}
+
+	resp := MMDBResponse{IP: ip, Networks: map[string]string{}}
+	found := false
+
+	if m.geo != nil {
+		var rec mmdbGeoRecord
+		network, ok, err := lookupMMDB(m.geo, addr, &rec)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			found = true
+			resp.Networks["geo"] = network
+			resp.Continent = rec.Continent.Code
+			resp.CountryCode = rec.Country.ISOCode
+			resp.Country = rec.Country.Names["en"]
+			if len(rec.Subdivisions) > 0 {
+				resp.Region = rec.Subdivisions[0].Names["en"]
+			}
+			resp.City = rec.City.Names["en"]
+			resp.PostalCode = rec.Postal.Code
+			resp.Latitude = rec.Location.Latitude
+			resp.Longitude = rec.Location.Longitude
+			resp.AccuracyRadius = rec.Location.AccuracyRadius
+			resp.TimeZone = rec.Location.TimeZone
+		}
+	}
+
+	if m.asn != nil {
+		var rec mmdbASNRecord
+		network, ok, err := lookupMMDB(m.asn, addr, &rec)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			found = true
+			resp.Networks["asn"] = network
+			resp.ASN = rec.Number
+			resp.ASOrg = rec.Organization
+		}
+	}
+
+	if !found {
+		return provider.NewSuccessResult(m, 0, nil), nil
+	}
+	return provider.NewSuccessResult(m, 0, resp), nil
+}
+
+// lookupMMDB looks addr up in a cached database and returns the matching network in CIDR form.
+func lookupMMDB(cache *fileCache[*mmdb.Reader], addr net.IP, v any) (string, bool, error) {
+	reader, err := cache.get()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	prefixLen, ok, err := reader.Lookup(addr, v)
+	if err != nil || !ok {
+		return "", ok, err
+	}
+
+	bits := 128
+	if v4 := addr.To4(); v4 != nil {
+		addr, bits = v4, 32
+	}
+	network := &net.IPNet{IP: addr.Mask(net.CIDRMask(prefixLen, bits)), Mask: net.CIDRMask(prefixLen, bits)}
+	return network.String(), true, nil
+}
+
+func init() {
+	provider.Register(NewMMDB())
+}
@@ -0,0 +1,250 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+const otxBaseURL = "https://otx.alienvault.com/api/v1/indicators/"
+
+// OTXResponse is the IP's OTX general and reputation data with its pulses.
+type OTXResponse struct {
+	IP              string            `json:"ip"`
+	ASN             string            `json:"asn,omitempty"`
+	Country         string            `json:"country,omitempty"`
+	Reputation      int               `json:"reputation"`
+	ThreatScore     int               `json:"threat_score,omitempty"`
+	Activities      []string          `json:"activities,omitempty"`
+	FirstSeen       string            `json:"first_seen,omitempty"`
+	LastSeen        string            `json:"last_seen,omitempty"`
+	Validation      []string          `json:"validation,omitempty"`
+	PulseCount      int               `json:"pulse_count"`
+	Pulses          []OTXPulse        `json:"pulses"`
+	Tags            []string          `json:"tags"`
+	Adversaries     []string          `json:"adversaries"`
+	MalwareFamilies []string          `json:"malware_families"`
+	SectionErrors   map[string]string `json:"section_errors,omitempty"`
+}
+
+// OTXPulse is a single OTX pulse that references the IP.
+type OTXPulse struct {
+	ID              string   `json:"id"`
+	Name            string   `json:"name"`
+	Author          string   `json:"author,omitempty"`
+	Tags            []string `json:"tags,omitempty"`
+	Adversary       string   `json:"adversary,omitempty"`
+	MalwareFamilies []string `json:"malware_families,omitempty"`
+	AttackIDs       []string `json:"attack_ids,omitempty"`
+	TLP             string   `json:"tlp,omitempty"`
+	Created         string   `json:"created,omitempty"`
+	Modified        string   `json:"modified,omitempty"`
+}
+
+// otxGeneral is the subset of the general section we read.
+type otxGeneral struct {
+	ASN         string `json:"asn"`
+	CountryCode string `json:"country_code"`
+	Reputation  int    `json:"reputation"`
+	Validation  []struct {
+		Source  string `json:"source"`
+		Message string `json:"message"`
+	} `json:"validation"`
+	PulseInfo struct {
+		Count  int `json:"count"`
+		Pulses []struct {
+			ID              string   `json:"id"`
+			Name            string   `json:"name"`
+			Tags            []string `json:"tags"`
+			Adversary       string   `json:"adversary"`
+			TLP             string   `json:"TLP"`
+			Created         string   `json:"created"`
+			Modified        string   `json:"modified"`
+			MalwareFamilies []struct {
+				DisplayName string `json:"display_name"`
+			} `json:"malware_families"`
+			AttackIDs []struct {
+				ID string `json:"id"`
+			} `json:"attack_ids"`
+			Author struct {
+				Username string `json:"username"`
+			} `json:"author"`
+		} `json:"pulses"`
+	} `json:"pulse_info"`
+}
+
+// otxReputation is the reputation section. Its body is null for IPs OTX has no opinion on.
+type otxReputation struct {
+	Reputation *struct {
+		ThreatScore int    `json:"threat_score"`
+		FirstSeen   string `json:"first_seen"`
+		LastSeen    string `json:"last_seen"`
+		Activities  []struct {
+			Name string `json:"name"`
+		} `json:"activities"`
+	} `json:"reputation"`
+}
+
+// otxError is the error body OTX returns with non-2xx statuses.
+type otxError struct {
+	Detail string `json:"detail"`
+}
+
+// OTX implements the LookupProvider interface for AlienVault OTX.
+type OTX struct {
+	provider.BaseProvider
+	headers map[string]string
+}
+
+// NewOTX creates a new OTX provider. It works without a key, at a lower rate limit.
+func NewOTX() *OTX {
+	return &OTX{
+		BaseProvider: provider.BaseProvider{
+			ProviderName: "AlienVault OTX",
+			ProviderID:   "otx",
+		},
+	}
+}
+
+// Configure applies the provider's settings.
+func (o *OTX) Configure(settings json.RawMessage) error {
+	var s provider.KeySettings
+	if err := json.Unmarshal(settings, &s); err != nil {
+		return err
+	}
+
+	o.headers = nil
+	if key := s.Key(); key != "" {
+		o.headers = map[string]string{"X-OTX-API-KEY": key}
+	}
+	return nil
+}
+
+// Lookup fetches the general section (which carries the pulses), then the reputation section.
+// A failed reputation call is reported in the response rather than failing the lookup.
+func (o *OTX) Lookup(ctx context.Context, ip string) (*provider.Result, error) {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return nil, fmt.Errorf("invalid IP address: %s", ip)
+	}
+
+	indicator := otxBaseURL + "IPv6/" + ip
+	if addr.To4() != nil {
+		indicator = otxBaseURL + "IPv4/" + ip
+	}
+
+	var general otxGeneral
+	statusCode, err := o.get(ctx, indicator+"/general", &general)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := OTXResponse{
+		IP:              ip,
+		ASN:             general.ASN,
+		Country:         general.CountryCode,
+		Reputation:      general.Reputation,
+		PulseCount:      general.PulseInfo.Count,
+		Pulses:          []OTXPulse{},
+		Tags:            []string{},
+		Adversaries:     []string{},
+		MalwareFamilies: []string{},
+	}
+	for _, v := range general.Validation {
+		resp.Validation = append(resp.Validation, v.Source+": "+v.Message)
+	}
+
+	for _, p := range general.PulseInfo.Pulses {
+		pulse := OTXPulse{
+			ID:        p.ID,
+			Name:      p.Name,
+			Author:    p.Author.Username,
+			Tags:      p.Tags,
+			Adversary: p.Adversary,
+			TLP:       p.TLP,
+			Created:   p.Created,
+			Modified:  p.Modified,
+		}
+		for _, m := range p.MalwareFamilies {
+			pulse.MalwareFamilies = append(pulse.MalwareFamilies, m.DisplayName)
+		}
+		for _, a := range p.AttackIDs {
+			pulse.AttackIDs = append(pulse.AttackIDs, a.ID)
+		}
+
+		resp.Pulses = append(resp.Pulses, pulse)
+		resp.Tags = appendUnique(resp.Tags, pulse.Tags...)
+		resp.MalwareFamilies = appendUnique(resp.MalwareFamilies, pulse.MalwareFamilies...)
+		if pulse.Adversary != "" {
+			resp.Adversaries = appendUnique(resp.Adversaries, pulse.Adversary)
+		}
+	}
+
+	var reputation otxReputation
+	if _, err := o.get(ctx, indicator+"/reputation", &reputation); err != nil {
+		resp.SectionErrors = map[string]string{"reputation": err.Error()}
+	} else if r := reputation.Reputation; r != nil {
+		resp.ThreatScore = r.ThreatScore
+		resp.FirstSeen = r.FirstSeen
+		resp.LastSeen = r.LastSeen
+		for _, a := range r.Activities {
+			resp.Activities = appendUnique(resp.Activities, a.Name)
+		}
+	}
+
+	result := provider.NewSuccessResult(o, statusCode, resp)
+	if msg, ok := resp.SectionErrors["reputation"]; ok {
+		result.Warnings = []string{"reputation: " + msg}
+	}
+	return result, nil
+}
+
+// get fetches an indicator section and decodes the JSON body into dst.
+// The detail OTX gives with an error status, such as a rejected key, is kept in the error.
+func (o *OTX) get(ctx context.Context, url string, dst any) (int, error) {
+	req, err := provider.NewRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	for k, v := range o.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := provider.Fetch(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		err := provider.NewStatusError(resp.StatusCode)
+		var body otxError
+		if json.Unmarshal(resp.Body, &body) == nil && body.Detail != "" {
+			err.Err = fmt.Errorf("%w: %s", err.Err, body.Detail)
+		}
+		return resp.StatusCode, err
+	}
+
+	if err := json.Unmarshal(resp.Body, dst); err != nil {
+		return resp.StatusCode, provider.NewParseError(err)
+	}
+	return resp.StatusCode, nil
+}
+
+// appendUnique appends the values not already in s.
+func appendUnique(s []string, values ...string) []string {
+	for _, v := range values {
+		if !slices.Contains(s, v) {
+			s = append(s, v)
+		}
+	}
+	return s
+}
+
+func init() {
+	provider.Register(NewOTX())
+}
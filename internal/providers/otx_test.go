@@ -0,0 +1,139 @@
+package providers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+const otxGeneralBody = `{"asn":"AS64500 EXAMPLE","country_code":"NL","reputation":0,
+	"validation":[{"source":"whitelist","message":"Whitelisted IP"}],
+	"pulse_info":{"count":2,"pulses":[
+		{"id":"p1","name":"Botnet C2","tags":["c2","botnet"],"adversary":"APT0","TLP":"white",
+			"malware_families":[{"display_name":"Emotet"}],"attack_ids":[{"id":"T1071"}],"author":{"username":"alice"}},
+		{"id":"p2","name":"Scanners","tags":["scanner","botnet"],"malware_families":[{"display_name":"Emotet"}]}]}}`
+
+func TestOTXLookup(t *testing.T) {
+	tests := []struct {
+		name         string
+		general      string
+		generalCode  int
+		reputation   string
+		repCode      int
+		wantKind     provider.ErrorKind
+		wantError    string
+		wantPulses   int
+		wantScore    int
+		wantWarnings []string
+	}{
+		{
+			name:       "pulses and reputation",
+			general:    otxGeneralBody,
+			reputation: `{"reputation":{"threat_score":5,"first_seen":"2024-01-01","activities":[{"name":"Scanning"},{"name":"Scanning"}]}}`,
+			wantPulses: 2,
+			wantScore:  5,
+		},
+		{
+			name:       "unknown to OTX",
+			general:    `{"reputation":0,"pulse_info":{"count":0,"pulses":[]}}`,
+			reputation: `{"reputation":null}`,
+		},
+		{
+			name:         "reputation fails",
+			general:      otxGeneralBody,
+			repCode:      http.StatusGatewayTimeout,
+			wantPulses:   2,
+			wantWarnings: []string{"reputation: "},
+		},
+		{
+			name:        "rejected key",
+			generalCode: http.StatusForbidden,
+			general:     `{"detail":"Authentication credentials were not provided."}`,
+			wantKind:    provider.ErrorKindAuth,
+			wantError:   "Authentication credentials were not provided.",
+		},
+		{
+			name:        "throttled",
+			generalCode: http.StatusTooManyRequests,
+			general:     `{"detail":"Request was throttled. Expected available in 60 seconds."}`,
+			wantKind:    provider.ErrorKindRateLimited,
+			wantError:   "Request was throttled.",
+		},
+		{name: "malformed", general: `{"pulse_info":`, wantKind: provider.ErrorKindParse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			otx := NewOTX()
+			if err := otx.Configure([]byte(`{"api_key":"test"}`)); err != nil {
+				t.Fatal(err)
+			}
+
+			result := runAgainst(t, otx, "192.0.2.1", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("X-OTX-API-KEY") != "test" {
+					t.Errorf("X-OTX-API-KEY = %q, want the configured key", r.Header.Get("X-OTX-API-KEY"))
+				}
+				switch r.URL.Path {
+				case "/api/v1/indicators/IPv4/192.0.2.1/general":
+					if tt.generalCode != 0 {
+						w.WriteHeader(tt.generalCode)
+					}
+					fmt.Fprint(w, tt.general)
+				case "/api/v1/indicators/IPv4/192.0.2.1/reputation":
+					if tt.repCode != 0 {
+						w.WriteHeader(tt.repCode)
+					}
+					fmt.Fprint(w, tt.reputation)
+				default:
+					t.Errorf("unexpected request for %s", r.URL.Path)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+
+			if result.ErrorKind != tt.wantKind {
+				t.Fatalf("ErrorKind = %q, want %q (%s)", result.ErrorKind, tt.wantKind, result.Error)
+			}
+			if !strings.Contains(result.Error, tt.wantError) {
+				t.Errorf("Error = %q, want it to contain %q", result.Error, tt.wantError)
+			}
+			if tt.wantKind != "" {
+				return
+			}
+
+			// OTX never sets a verdict: popular infrastructure appears in many pulses.
+			if result.Verdict != "" {
+				t.Errorf("Verdict = %q, want none", result.Verdict)
+			}
+			if len(result.Warnings) != len(tt.wantWarnings) {
+				t.Fatalf("Warnings = %q, want %q", result.Warnings, tt.wantWarnings)
+			}
+			for i, prefix := range tt.wantWarnings {
+				if !strings.HasPrefix(result.Warnings[i], prefix) {
+					t.Errorf("warning %d = %q, want prefix %q", i, result.Warnings[i], prefix)
+				}
+			}
+
+			resp := result.Raw.(OTXResponse)
+			if len(resp.Pulses) != tt.wantPulses || resp.ThreatScore != tt.wantScore {
+				t.Errorf("got %d pulses and threat score %d, want %d and %d", len(resp.Pulses), resp.ThreatScore, tt.wantPulses, tt.wantScore)
+			}
+			if tt.wantPulses == 0 {
+				return
+			}
+			if !slices.Equal(resp.Tags, []string{"c2", "botnet", "scanner"}) || !slices.Equal(resp.MalwareFamilies, []string{"Emotet"}) ||
+				!slices.Equal(resp.Adversaries, []string{"APT0"}) || !slices.Equal(resp.Validation, []string{"whitelist: Whitelisted IP"}) {
+				t.Errorf("pulse data not merged: %+v", resp)
+			}
+			if p := resp.Pulses[0]; p.Author != "alice" || !slices.Equal(p.AttackIDs, []string{"T1071"}) {
+				t.Errorf("first pulse = %+v", p)
+			}
+			if tt.wantScore > 0 && !slices.Equal(resp.Activities, []string{"Scanning"}) {
+				t.Errorf("Activities = %q, want [Scanning]", resp.Activities)
+			}
+		})
+	}
+}
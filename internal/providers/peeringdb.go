@@ -0,0 +1,283 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+const peeringDBBaseURL = "https://www.peeringdb.com/api/net"
+
+// peeringDBASNSources are the providers PeeringDB can take the IP's ASN from.
+var peeringDBASNSources = []string{"cymru", "ripestat", "bgpview", "ipapi", "ipwhois", "ipinfo"}
+
+// PeeringDBResponse is the PeeringDB network record for the IP's ASN.
+type PeeringDBResponse struct {
+	ASN           int                 `json:"asn"`
+	ASNSource     string              `json:"asn_source"`
+	Name          string              `json:"name"`
+	AKA           string              `json:"aka,omitempty"`
+	Types         []string            `json:"types"`
+	Website       string              `json:"website,omitempty"`
+	IRRASSet      string              `json:"irr_as_set,omitempty"`
+	Scope         string              `json:"scope,omitempty"`
+	Traffic       string              `json:"traffic,omitempty"`
+	Ratio         string              `json:"ratio,omitempty"`
+	Prefixes4     int                 `json:"prefixes4"`
+	Prefixes6     int                 `json:"prefixes6"`
+	Policy        PeeringDBPolicy     `json:"policy"`
+	Exchanges     []PeeringDBExchange `json:"exchanges"`
+	FacilityCount int                 `json:"facility_count"`
+	Updated       string              `json:"updated,omitempty"`
+}
+
+// PeeringDBPolicy is the network's published peering policy.
+type PeeringDBPolicy struct {
+	General   string `json:"general,omitempty"`
+	URL       string `json:"url,omitempty"`
+	Locations string `json:"locations,omitempty"`
+	Ratio     bool   `json:"ratio"`
+	Contracts string `json:"contracts,omitempty"`
+}
+
+// PeeringDBExchange is a port the network has on an internet exchange.
+type PeeringDBExchange struct {
+	Name      string `json:"name"`
+	IXID      int    `json:"ix_id"`
+	SpeedMbps int    `json:"speed_mbps"`
+	IPv4      string `json:"ipv4,omitempty"`
+	IPv6      string `json:"ipv6,omitempty"`
+	RSPeer    bool   `json:"route_server_peer"`
+}
+
+// PeeringDBSettings are the config file settings for the PeeringDB provider.
+type PeeringDBSettings struct {
+	provider.KeySettings
+
+	// ASNFrom lists the providers to take the ASN from, in order of preference (default ["cymru"]).
+	ASNFrom []string `json:"asn_from"`
+}
+
+// peeringDBNets is the /api/net response at depth 2, which expands the IX ports.
+type peeringDBNets struct {
+	Data []struct {
+		ASN             int      `json:"asn"`
+		Name            string   `json:"name"`
+		AKA             string   `json:"aka"`
+		Website         string   `json:"website"`
+		IRRASSet        string   `json:"irr_as_set"`
+		InfoType        string   `json:"info_type"`
+		InfoTypes       []string `json:"info_types"`
+		InfoScope       string   `json:"info_scope"`
+		InfoTraffic     string   `json:"info_traffic"`
+		InfoRatio       string   `json:"info_ratio"`
+		InfoPrefixes4   int      `json:"info_prefixes4"`
+		InfoPrefixes6   int      `json:"info_prefixes6"`
+		PolicyGeneral   string   `json:"policy_general"`
+		PolicyURL       string   `json:"policy_url"`
+		PolicyLocations string   `json:"policy_locations"`
+		PolicyRatio     bool     `json:"policy_ratio"`
+		PolicyContracts string   `json:"policy_contracts"`
+		Updated         string   `json:"updated"`
+		NetfacSet       []any    `json:"netfac_set"`
+		NetixlanSet     []struct {
+			IXID     int    `json:"ix_id"`
+			Name     string `json:"name"`
+			Speed    int    `json:"speed"`
+			IPAddr4  string `json:"ipaddr4"`
+			IPAddr6  string `json:"ipaddr6"`
+			IsRSPeer bool   `json:"is_rs_peer"`
+		} `json:"netixlan_set"`
+	} `json:"data"`
+}
+
+// PeeringDB implements the LookupProvider interface for the PeeringDB API.
+// It depends on another provider to resolve the IP's ASN.
+type PeeringDB struct {
+	provider.BaseProvider
+	asnFrom []string
+	headers map[string]string
+}
+
+// NewPeeringDB creates a new PeeringDB provider. It works without an API key at a lower rate limit.
+func NewPeeringDB() *PeeringDB {
+	return &PeeringDB{
+		BaseProvider: provider.BaseProvider{
+			ProviderName: "PeeringDB",
+			ProviderID:   "peeringdb",
+		},
+		asnFrom: []string{"cymru"},
+	}
+}
+
+// Configure applies the provider's settings.
+func (p *PeeringDB) Configure(settings json.RawMessage) error {
+	var s PeeringDBSettings
+	if err := json.Unmarshal(settings, &s); err != nil {
+		return err
+	}
+
+	for _, id := range s.ASNFrom {
+		if !slices.Contains(peeringDBASNSources, id) {
+			return fmt.Errorf("asn_from: unsupported provider %q (supported: %v)", id, peeringDBASNSources)
+		}
+	}
+	p.asnFrom = []string{"cymru"}
+	if len(s.ASNFrom) > 0 {
+		p.asnFrom = s.ASNFrom
+	}
+
+	// The key is optional, so only send it once one is set.
+	p.headers = nil
+	if key := s.Key(); key != "" {
+		p.headers = map[string]string{"Authorization": "Api-Key " + key}
+	}
+	return nil
+}
+
+// DependsOn returns the providers the ASN is taken from.
+func (p *PeeringDB) DependsOn() []string {
+	return p.asnFrom
+}
+
+// Lookup takes the ASN from the first upstream provider that resolved one and fetches its network record.
+func (p *PeeringDB) Lookup(ctx context.Context, ip string) (*provider.Result, error) {
+	asn, source, err := p.upstreamASN(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Unrouted IPs have no ASN and so nothing to look up
+	if asn == 0 {
+		return provider.NewSuccessResult(p, 0, nil), nil
+	}
+
+	var nets peeringDBNets
+	url := peeringDBBaseURL + "?depth=2&asn=" + strconv.Itoa(asn)
+	statusCode, err := getJSON(ctx, url, p.headers, &nets)
+	if err != nil {
+		return nil, err
+	}
+	// Networks that have not registered with PeeringDB return an empty list
+	if len(nets.Data) == 0 {
+		return provider.NewSuccessResult(p, statusCode, nil), nil
+	}
+
+	n := nets.Data[0]
+	resp := PeeringDBResponse{
+		ASN:       n.ASN,
+		ASNSource: source,
+		Name:      n.Name,
+		AKA:       n.AKA,
+		Types:     nonNil(n.InfoTypes),
+		Website:   n.Website,
+		IRRASSet:  n.IRRASSet,
+		Scope:     n.InfoScope,
+		Traffic:   n.InfoTraffic,
+		Ratio:     n.InfoRatio,
+		Prefixes4: n.InfoPrefixes4,
+		Prefixes6: n.InfoPrefixes6,
+		Policy: PeeringDBPolicy{
+			General:   n.PolicyGeneral,
+			URL:       n.PolicyURL,
+			Locations: n.PolicyLocations,
+			Ratio:     n.PolicyRatio,
+			Contracts: n.PolicyContracts,
+		},
+		Exchanges:     make([]PeeringDBExchange, 0, len(n.NetixlanSet)),
+		FacilityCount: len(n.NetfacSet),
+		Updated:       n.Updated,
+	}
+	// Older records only have the deprecated single info_type.
+	if len(resp.Types) == 0 && n.InfoType != "" {
+		resp.Types = []string{n.InfoType}
+	}
+	for _, x := range n.NetixlanSet {
+		resp.Exchanges = append(resp.Exchanges, PeeringDBExchange{
+			Name:      x.Name,
+			IXID:      x.IXID,
+			SpeedMbps: x.Speed,
+			IPv4:      x.IPAddr4,
+			IPv6:      x.IPAddr6,
+			RSPeer:    x.IsRSPeer,
+		})
+	}
+
+	return provider.NewSuccessResult(p, statusCode, resp), nil
+}
+
+// upstreamASN returns the ASN from the first configured provider that resolved one, and that provider's ID.
+// It returns a zero ASN if the providers succeeded without one,
+// and a dependency error if none of them succeeded.
+func (p *PeeringDB) upstreamASN(ctx context.Context) (int, string, error) {
+	var failed []string
+	for _, id := range p.asnFrom {
+		r, ok := provider.Upstream(ctx, id)
+		if !ok || r.Error != "" {
+			failed = append(failed, id)
+			continue
+		}
+		if asn := resultASN(r.Raw); asn != 0 {
+			return asn, id, nil
+		}
+	}
+
+	if len(failed) == len(p.asnFrom) {
+		return 0, "", provider.NewError(provider.ErrorKindDependency,
+			fmt.Errorf("no ASN: %s failed or did not run", strings.Join(failed, ", ")))
+	}
+	return 0, "", nil
+}
+
+// resultASN extracts the origin ASN from another provider's response, or returns zero.
+func resultASN(raw any) int {
+	switch r := raw.(type) {
+	case CymruResponse:
+		return r.ASN
+	case RIPEstatResponse:
+		if len(r.Origins) > 0 {
+			return r.Origins[0].ASN
+		}
+	case BGPViewResponse:
+		// Prefixes are not ordered by specificity; the most specific one's origin is the IP's.
+		if asn := originOf(r, r.Prefix); asn != 0 {
+			return asn
+		}
+		if len(r.Data.Prefixes) > 0 {
+			return r.Data.Prefixes[0].ASN.ASN
+		}
+	case IPAPIResponse:
+		return r.ASN.ASN
+	case IPWhoisResponse:
+		return r.Connection.ASN
+	case IPInfoResponse:
+		// Paid plans return the ASN on its own; the free tier prefixes it to org.
+		if r.ASN != nil {
+			return parseASN(r.ASN.ASN)
+		}
+		org, _, _ := strings.Cut(r.Org, " ")
+		return parseASN(org)
+	}
+	return 0
+}
+
+// parseASN parses an "AS15169" style AS number, or returns zero.
+func parseASN(s string) int {
+	digits, ok := strings.CutPrefix(strings.ToUpper(s), "AS")
+	if !ok {
+		return 0
+	}
+	n, err := strconv.Atoi(digits)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+func init() {
+	provider.Register(NewPeeringDB())
+}
@@ -0,0 +1,140 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// parsed returns the Raw value an HTTP provider produces for body.
+func parsed(t *testing.T, p provider.HTTPProvider, body string) any {
+	t.Helper()
+
+	result, err := p.ParseResponse([]byte(body), http.StatusOK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Error != "" {
+		t.Fatalf("%s: %s", p.ID(), result.Error)
+	}
+	return result.Raw
+}
+
+func TestResultASN(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  func(t *testing.T) any
+		want int
+	}{
+		{
+			name: "cymru",
+			raw: func(t *testing.T) any {
+				c := NewCymru()
+				c.resolver = fakeTXT{records: map[string][]string{
+					"8.8.8.8.origin.asn.cymru.com": {"15169 | 8.8.8.0/24 | US | arin | 2023-12-28"},
+				}}
+				result, err := c.Lookup(context.Background(), "8.8.8.8")
+				if err != nil {
+					t.Fatal(err)
+				}
+				return result.Raw
+			},
+			want: 15169,
+		},
+		{
+			name: "ripestat",
+			raw: func(t *testing.T) any {
+				return runAgainst(t, NewRIPEstat(), "8.8.8.8", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					switch r.URL.Path {
+					case "/data/prefix-overview/data.json":
+						fmt.Fprint(w, `{"status":"ok","data":{"resource":"8.8.8.0/24","announced":true,"asns":[{"asn":15169,"holder":"GOOGLE"}]}}`)
+					default:
+						fmt.Fprint(w, `{"status":"ok","data":{}}`)
+					}
+				})).Raw
+			},
+			want: 15169,
+		},
+		{
+			name: "bgpview most specific prefix",
+			raw: func(t *testing.T) any {
+				return runAgainst(t, NewBGPView(), "1.1.1.1", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if r.URL.Path != "/ip/1.1.1.1" {
+						http.NotFound(w, r)
+						return
+					}
+					fmt.Fprint(w, bgpviewIPBody)
+				})).Raw
+			},
+			want: 13335,
+		},
+		{
+			name: "ipapi",
+			raw: func(t *testing.T) any {
+				return parsed(t, NewIPAPI(), `{"ip":"8.8.8.8","asn":{"asn":15169,"org":"Google LLC","route":"8.8.8.0/24"}}`)
+			},
+			want: 15169,
+		},
+		{
+			name: "ipwhois",
+			raw: func(t *testing.T) any {
+				return parsed(t, NewIPWhois(), `{"ip":"8.8.8.8","success":true,"connection":{"asn":15169,"org":"Google LLC"}}`)
+			},
+			want: 15169,
+		},
+		{
+			name: "ipinfo paid plan",
+			raw: func(t *testing.T) any {
+				return parsed(t, NewIPInfo(), `{"ip":"8.8.8.8","asn":{"asn":"AS15169","name":"Google LLC","route":"8.8.8.0/24"}}`)
+			},
+			want: 15169,
+		},
+		{
+			name: "ipinfo free tier org",
+			raw: func(t *testing.T) any {
+				return parsed(t, NewIPInfo(), `{"ip":"8.8.8.8","org":"AS15169 Google LLC"}`)
+			},
+			want: 15169,
+		},
+		{
+			name: "ipinfo without ASN",
+			raw: func(t *testing.T) any {
+				return parsed(t, NewIPInfo(), `{"ip":"192.0.2.1","bogon":true}`)
+			},
+		},
+		{name: "no data", raw: func(*testing.T) any { return nil }},
+		{name: "unsupported provider", raw: func(*testing.T) any { return TorExitResponse{} }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resultASN(tt.raw(t)); got != tt.want {
+				t.Errorf("resultASN = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseASN(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{in: "AS15169", want: 15169},
+		{in: "as13335", want: 13335},
+		{in: "15169"},
+		{in: "AS"},
+		{in: "ASN15169"},
+		{in: "AS-1"},
+		{in: ""},
+	}
+
+	for _, tt := range tests {
+		if got := parseASN(tt.in); got != tt.want {
+			t.Errorf("parseASN(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// rdapBootstrapURLs are the IANA RDAP bootstrap files, keyed by IP version.
+var rdapBootstrapURLs = map[int]string{
+	4: "https://data.iana.org/rdap/ipv4.json",
+	6: "https://data.iana.org/rdap/ipv6.json",
+}
+
+// rdapHeaders asks servers for RDAP JSON rather than plain JSON.
+var rdapHeaders = map[string]string{"Accept": "application/rdap+json"}
+
+// rdapMaxReferrals bounds how many "related" links we follow to a more specific registry.
+const rdapMaxReferrals = 3
+
+// RDAPResponse is the registration data extracted from an RDAP IP network object.
+type RDAPResponse struct {
+	Handle       string   `json:"handle"`
+	Name         string   `json:"name"`
+	Type         string   `json:"type"`
+	Country      string   `json:"country"`
+	StartAddress string   `json:"start_address"`
+	EndAddress   string   `json:"end_address"`
+	CIDRs        []string `json:"cidrs"`
+	Registrant   string   `json:"registrant"`
+	AbuseEmail   string   `json:"abuse_email"`
+	Registered   string   `json:"registered"`
+	LastChanged  string   `json:"last_changed"`
+	Source       string   `json:"source"`
+}
+
+// rdapNetwork is the subset of an RFC 9083 IP network object we read.
+type rdapNetwork struct {
+	Handle       string       `json:"handle"`
+	Name         string       `json:"name"`
+	Type         string       `json:"type"`
+	Country      string       `json:"country"`
+	StartAddress string       `json:"startAddress"`
+	EndAddress   string       `json:"endAddress"`
+	CIDR0        []rdapCIDR   `json:"cidr0_cidrs"`
+	Entities     []rdapEntity `json:"entities"`
+	Events       []rdapEvent  `json:"events"`
+	Links        []rdapLink   `json:"links"`
+}
+
+type rdapCIDR struct {
+	V4Prefix string `json:"v4prefix"`
+	V6Prefix string `json:"v6prefix"`
+	Length   int    `json:"length"`
+}
+
+type rdapEntity struct {
+	Roles      []string     `json:"roles"`
+	VCardArray []any        `json:"vcardArray"`
+	Entities   []rdapEntity `json:"entities"`
+}
+
+type rdapEvent struct {
+	Action string `json:"eventAction"`
+	Date   string `json:"eventDate"`
+}
+
+type rdapLink struct {
+	Rel  string `json:"rel"`
+	Href string `json:"href"`
+	Type string `json:"type"`
+}
+
+// rdapBootstrap is an IANA bootstrap file: each service maps prefixes to base URLs.
+type rdapBootstrap struct {
+	Services [][][]string `json:"services"`
+}
+
+// RDAP implements the LookupProvider interface for the Registration Data Access Protocol.
+type RDAP struct {
+	provider.BaseProvider
+
+	mu        sync.Mutex
+	bootstrap map[int]*rdapBootstrap
+}
+
+// NewRDAP creates a new RDAP provider.
+func NewRDAP() *RDAP {
+	return &RDAP{
+		BaseProvider: provider.BaseProvider{
+			ProviderName: "RDAP",
+			ProviderID:   "rdap",
+		},
+		bootstrap: make(map[int]*rdapBootstrap),
+	}
+}
+
+// Lookup finds the responsible RIR via the IANA bootstrap and queries its RDAP server.
+func (r *RDAP) Lookup(ctx context.Context, ip string) (*provider.Result, error) {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return nil, fmt.Errorf("invalid IP address: %s", ip)
+	}
+
+	base, err := r.serverFor(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	// Reserved and special-purpose space has no RDAP service
+	if base == "" {
+		return provider.NewSuccessResult(r, 0, nil), nil
+	}
+
+	source := strings.TrimSuffix(base, "/") + "/ip/" + ip
+	var network rdapNetwork
+	statusCode, err := getJSON(ctx, source, rdapHeaders, &network)
+	if err != nil {
+		return nil, err
+	}
+
+	// Follow referrals to a more specific registry (e.g. an NIR or an LIR's own server).
+	for range rdapMaxReferrals {
+		next := relatedLink(network.Links, source)
+		if next == "" {
+			break
+		}
+
+		var referred rdapNetwork
+		code, err := getJSON(ctx, next, rdapHeaders, &referred)
+		if err != nil {
+			break
+		}
+		network, source, statusCode = referred, next, code
+	}
+
+	return provider.NewSuccessResult(r, statusCode, network.summary(source)), nil
+}
+
+// serverFor returns the RDAP base URL responsible for addr, or "" if none is.
+func (r *RDAP) serverFor(ctx context.Context, addr net.IP) (string, error) {
+	version := 6
+	if addr.To4() != nil {
+		version = 4
+	}
+
+	bootstrap, err := r.loadBootstrap(ctx, version)
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		best    string
+		bestLen = -1
+	)
+	for _, service := range bootstrap.Services {
+		if len(service) < 2 || len(service[1]) == 0 {
+			continue
+		}
+		for _, prefix := range service[0] {
+			_, network, err := net.ParseCIDR(prefix)
+			if err != nil || !network.Contains(addr) {
+				continue
+			}
+			if ones, _ := network.Mask.Size(); ones > bestLen {
+				best, bestLen = preferHTTPS(service[1]), ones
+			}
+		}
+	}
+
+	return best, nil
+}
+
+// loadBootstrap fetches and caches the IANA bootstrap file for an IP version.
+func (r *RDAP) loadBootstrap(ctx context.Context, version int) (*rdapBootstrap, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if b, ok := r.bootstrap[version]; ok {
+		return b, nil
+	}
+
+	var b rdapBootstrap
+	if _, err := getJSON(ctx, rdapBootstrapURLs[version], nil, &b); err != nil {
+		return nil, fmt.Errorf("failed to load RDAP bootstrap: %w", err)
+	}
+
+	r.bootstrap[version] = &b
+	return &b, nil
+}
+
+// preferHTTPS picks the first https URL, falling back to the first URL.
+func preferHTTPS(urls []string) string {
+	for _, u := range urls {
+		if strings.HasPrefix(u, "https://") {
+			return u
+		}
+	}
+	return urls[0]
+}
+
+// relatedLink returns an RDAP "related" link that points somewhere other than current.
+func relatedLink(links []rdapLink, current string) string {
+	for _, l := range links {
+		if l.Rel != "related" || l.Href == "" || l.Href == current {
+			continue
+		}
+		if l.Type == "" || l.Type == "application/rdap+json" {
+			return l.Href
+		}
+	}
+	return ""
+}
+
+// summary flattens the network object into an RDAPResponse.
+func (n *rdapNetwork) summary(source string) RDAPResponse {
+	resp := RDAPResponse{
+		Handle:       n.Handle,
+		Name:         n.Name,
+		Type:         n.Type,
+		Country:      n.Country,
+		StartAddress: n.StartAddress,
+		EndAddress:   n.EndAddress,
+		Source:       source,
+	}
+
+	for _, c := range n.CIDR0 {
+		prefix := c.V4Prefix
+		if prefix == "" {
+			prefix = c.V6Prefix
+		}
+		resp.CIDRs = append(resp.CIDRs, fmt.Sprintf("%s/%d", prefix, c.Length))
+	}
+
+	for _, e := range n.Events {
+		switch e.Action {
+		case "registration":
+			resp.Registered = e.Date
+		case "last changed":
+			resp.LastChanged = e.Date
+		}
+	}
+
+	walkEntities(n.Entities, func(e rdapEntity) {
+		if resp.Registrant == "" && slices.Contains(e.Roles, "registrant") {
+			resp.Registrant = vcardValue(e.VCardArray, "fn")
+		}
+		if resp.AbuseEmail == "" && slices.Contains(e.Roles, "abuse") {
+			resp.AbuseEmail = vcardValue(e.VCardArray, "email")
+		}
+	})
+
+	return resp
+}
+
+// walkEntities visits entities depth-first, including nested entities.
+func walkEntities(entities []rdapEntity, visit func(rdapEntity)) {
+	for _, e := range entities {
+		visit(e)
+		walkEntities(e.Entities, visit)
+	}
+}
+
+// vcardValue returns the first text value of a jCard property,
+// e.g. ["vcard", [["fn", {}, "text", "Example Org"], ...]].
+func vcardValue(vcard []any, property string) string {
+	if len(vcard) < 2 {
+		return ""
+	}
+	props, ok := vcard[1].([]any)
+	if !ok {
+		return ""
+	}
+
+	for _, p := range props {
+		fields, ok := p.([]any)
+		if !ok || len(fields) < 4 {
+			continue
+		}
+		if name, _ := fields[0].(string); name != property {
+			continue
+		}
+		if value, ok := fields[3].(string); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+func init() {
+	provider.Register(NewRDAP())
+}
@@ -0,0 +1,204 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+const rdapTestBootstrap = `{"services":[
+	[["8.0.0.0/8"],["http://rdap.arin.net/registry/","https://rdap.arin.net/registry/"]],
+	[["193.0.0.0/8"],["https://rdap.db.ripe.net/"]],
+	[["133.0.0.0/8"],["https://rdap.apnic.net/"]]]}`
+
+func TestRDAPLookup(t *testing.T) {
+	fixtures := map[string]string{
+		"/registry/ip/8.8.8.8": "arin.json",
+		"/ip/193.0.6.139":      "ripe.json",
+		"/ip/133.242.0.1":      "apnic-referral.json",
+		"/rdap/ip/133.242.0.1": "jpnic.json",
+	}
+
+	tests := []struct {
+		name           string
+		ip             string
+		failReferral   bool
+		wantHandle     string
+		wantRegistrant string
+		wantAbuse      string
+		wantCIDRs      []string
+		wantSource     string
+	}{
+		{
+			name:           "ARIN nested abuse entity",
+			ip:             "8.8.8.8",
+			wantHandle:     "NET-8-8-8-0-2",
+			wantRegistrant: "Google LLC",
+			wantAbuse:      "network-abuse@google.com",
+			wantCIDRs:      []string{"8.8.8.0/24"},
+			wantSource:     "https://rdap.arin.net/registry/ip/8.8.8.8",
+		},
+		{
+			name:           "RIPE top-level entities",
+			ip:             "193.0.6.139",
+			wantHandle:     "193.0.0.0 - 193.0.7.255",
+			wantRegistrant: "Reseaux IP Europeens Network Coordination Centre (RIPE NCC)",
+			wantAbuse:      "abuse@ripe.net",
+			wantCIDRs:      []string{"193.0.0.0/21"},
+			wantSource:     "https://rdap.db.ripe.net/ip/193.0.6.139",
+		},
+		{
+			name:           "related link to a national registry",
+			ip:             "133.242.0.1",
+			wantHandle:     "133.242.0.0 - 133.242.255.255",
+			wantRegistrant: "SAKURA Internet Inc.",
+			wantAbuse:      "abuse@sakura.ad.jp",
+			wantCIDRs:      []string{"133.242.0.0/16"},
+			wantSource:     "https://rdap.nic.ad.jp/rdap/ip/133.242.0.1",
+		},
+		{
+			name:           "failed referral keeps the RIR answer",
+			ip:             "133.242.0.1",
+			failReferral:   true,
+			wantHandle:     "133.0.0.0 - 133.255.255.255",
+			wantRegistrant: "Japan Network Information Center",
+			wantCIDRs:      []string{"133.0.0.0/8"},
+			wantSource:     "https://rdap.apnic.net/ip/133.242.0.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := runAgainst(t, NewRDAP(), tt.ip, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/rdap/ipv4.json" {
+					fmt.Fprint(w, rdapTestBootstrap)
+					return
+				}
+				file, ok := fixtures[r.URL.Path]
+				if !ok || (tt.failReferral && file == "jpnic.json") {
+					http.NotFound(w, r)
+					return
+				}
+				if accept := r.Header.Get("Accept"); accept != "application/rdap+json" {
+					t.Errorf("Accept = %q", accept)
+				}
+				http.ServeFile(w, r, filepath.Join("testdata", "rdap", file))
+			}))
+
+			if result.Error != "" {
+				t.Fatalf("unexpected error: %s", result.Error)
+			}
+			resp := result.Raw.(RDAPResponse)
+			if resp.Handle != tt.wantHandle || resp.Registrant != tt.wantRegistrant || resp.AbuseEmail != tt.wantAbuse {
+				t.Errorf("got handle %q registrant %q abuse %q", resp.Handle, resp.Registrant, resp.AbuseEmail)
+			}
+			if !slices.Equal(resp.CIDRs, tt.wantCIDRs) || resp.Source != tt.wantSource {
+				t.Errorf("got cidrs %v source %q", resp.CIDRs, resp.Source)
+			}
+		})
+	}
+}
+
+func TestRDAPSummaryEvents(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "rdap", "ripe.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var network rdapNetwork
+	if err := json.Unmarshal(data, &network); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := network.summary("source")
+	if resp.Registered != "2003-03-17T12:15:57Z" || resp.LastChanged != "2017-12-04T14:42:31Z" || resp.Country != "NL" {
+		t.Errorf("got %+v", resp)
+	}
+}
+
+func TestRDAPLookupNoService(t *testing.T) {
+	result := runAgainst(t, NewRDAP(), "10.0.0.1", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rdap/ipv4.json" {
+			t.Errorf("unexpected request for %s", r.URL.Path)
+		}
+		fmt.Fprint(w, rdapTestBootstrap)
+	}))
+
+	if result.Error != "" || result.Raw != nil {
+		t.Errorf("got %+v, want an empty result for space without an RDAP service", result)
+	}
+}
+
+func TestRelatedLink(t *testing.T) {
+	const current = "https://rdap.apnic.net/ip/1.2.3.4"
+	tests := []struct {
+		name  string
+		links []rdapLink
+		want  string
+	}{
+		{name: "none"},
+		{name: "self only", links: []rdapLink{{Rel: "self", Href: current}}},
+		{name: "related to current", links: []rdapLink{{Rel: "related", Href: current}}},
+		{name: "related html", links: []rdapLink{{Rel: "related", Href: "https://example.jp/", Type: "text/html"}}},
+		{name: "related without href", links: []rdapLink{{Rel: "related", Type: "application/rdap+json"}}},
+		{
+			name:  "untyped related",
+			links: []rdapLink{{Rel: "related", Href: "https://rdap.example/ip/1.2.3.4"}},
+			want:  "https://rdap.example/ip/1.2.3.4",
+		},
+		{
+			name: "first rdap link after others",
+			links: []rdapLink{
+				{Rel: "related", Href: "https://example.jp/", Type: "text/html"},
+				{Rel: "related", Href: "https://rdap.example/a", Type: "application/rdap+json"},
+				{Rel: "related", Href: "https://rdap.example/b", Type: "application/rdap+json"},
+			},
+			want: "https://rdap.example/a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := relatedLink(tt.links, current); got != tt.want {
+				t.Errorf("relatedLink = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVCardValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		vcard    string
+		property string
+		want     string
+	}{
+		{name: "fn", vcard: `["vcard", [["version", {}, "text", "4.0"], ["fn", {}, "text", "Example Org"]]]`, property: "fn", want: "Example Org"},
+		{name: "first of several", vcard: `["vcard", [["email", {}, "text", "a@example.com"], ["email", {}, "text", "b@example.com"]]]`, property: "email", want: "a@example.com"},
+		{name: "structured value skipped", vcard: `["vcard", [["fn", {}, "text", ["a", "b"]], ["fn", {}, "text", "Plain"]]]`, property: "fn", want: "Plain"},
+		{name: "missing property", vcard: `["vcard", [["fn", {}, "text", "Example Org"]]]`, property: "email"},
+		{name: "empty", vcard: `[]`, property: "fn"},
+		{name: "no properties", vcard: `["vcard"]`, property: "fn"},
+		{name: "properties not an array", vcard: `["vcard", {"fn": "Example Org"}]`, property: "fn"},
+		{name: "property not an array", vcard: `["vcard", ["fn", {}, "text", "Example Org"]]`, property: "fn"},
+		{name: "short property", vcard: `["vcard", [["fn", {}, "text"], ["fn"]]]`, property: "fn"},
+		{name: "non-string name", vcard: `["vcard", [[42, {}, "text", "Example Org"], [null, {}, "text", "x"]]]`, property: "fn"},
+		{name: "null value", vcard: `["vcard", [["email", {}, "text", null]]]`, property: "email"},
+		{name: "nulls", vcard: `[null, null]`, property: "fn"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var vcard []any
+			if err := json.Unmarshal([]byte(tt.vcard), &vcard); err != nil {
+				t.Fatal(err)
+			}
+			if got := vcardValue(vcard, tt.property); got != tt.want {
+				t.Errorf("vcardValue = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// ReverseDNSResponse contains the PTR names for an IP and whether they forward-confirm.
+type ReverseDNSResponse struct {
+	IP        string               `json:"ip"`
+	Hostnames []ReverseDNSHostname `json:"hostnames"`
+	Confirmed bool                 `json:"confirmed"`
+}
+
+// ReverseDNSHostname is a single PTR name and the addresses it forward-resolves to.
+type ReverseDNSHostname struct {
+	Name      string   `json:"name"`
+	Addresses []string `json:"addresses"`
+	Confirmed bool     `json:"confirmed"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// ReverseDNSSettings are the config file settings for the reverse DNS provider.
+type ReverseDNSSettings struct {
+	// Resolver is a DNS server (host:port) to query instead of the system resolver.
+	Resolver string `json:"resolver"`
+}
+
+// addrResolver looks up PTR names and their addresses. *net.Resolver implements it;
+// tests substitute their own.
+type addrResolver interface {
+	LookupAddr(ctx context.Context, addr string) ([]string, error)
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// dnsResolver queries server, or the resolv.conf nameserver, directly. Unlike net.Resolver
+// it never answers from /etc/hosts, so a local hosts entry cannot pass for a PTR record
+// or confirm one.
+type dnsResolver struct {
+	server string
+}
+
+// LookupAddr returns the PTR names for addr.
+func (d dnsResolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address: %s", addr)
+	}
+	return lookupPTR(ctx, d.server, ip)
+}
+
+// LookupIPAddr returns the A and AAAA records for host.
+func (d dnsResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	return lookupIP(ctx, d.server, host)
+}
+
+// ReverseDNS implements the LookupProvider interface for PTR and forward-confirmed reverse DNS.
+type ReverseDNS struct {
+	provider.BaseProvider
+	resolver addrResolver
+}
+
+// NewReverseDNS creates a new reverse DNS provider.
+func NewReverseDNS() *ReverseDNS {
+	return &ReverseDNS{
+		BaseProvider: provider.BaseProvider{
+			ProviderName: "Reverse DNS",
+			ProviderID:   "rdns",
+		},
+		resolver: dnsResolver{},
+	}
+}
+
+// Configure applies the provider's settings.
+func (r *ReverseDNS) Configure(settings json.RawMessage) error {
+	var s ReverseDNSSettings
+	if err := json.Unmarshal(settings, &s); err != nil {
+		return err
+	}
+	r.resolver = dnsResolver{server: s.Resolver}
+	return nil
+}
+
+// Lookup resolves the IP's PTR records, then forward-resolves each name
+// to check that it maps back to the IP (FCrDNS).
+func (r *ReverseDNS) Lookup(ctx context.Context, ip string) (*provider.Result, error) {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return nil, fmt.Errorf("invalid IP address: %s", ip)
+	}
+
+	names, err := r.resolver.LookupAddr(ctx, ip)
+	if isNotFound(err) {
+		return provider.NewSuccessResult(r, 0, nil), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp := ReverseDNSResponse{IP: ip}
+	for _, name := range names {
+		hostname := ReverseDNSHostname{Name: strings.TrimSuffix(name, ".")}
+
+		addrs, err := r.resolver.LookupIPAddr(ctx, name)
+		if err != nil {
+			hostname.Error = err.Error()
+		}
+		for _, a := range addrs {
+			hostname.Addresses = append(hostname.Addresses, a.IP.String())
+			if a.IP.Equal(addr) {
+				hostname.Confirmed = true
+			}
+		}
+
+		resp.Confirmed = resp.Confirmed || hostname.Confirmed
+		resp.Hostnames = append(resp.Hostnames, hostname)
+	}
+
+	return provider.NewSuccessResult(r, 0, resp), nil
+}
+
+func init() {
+	provider.Register(NewReverseDNS())
+}
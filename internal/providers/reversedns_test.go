@@ -0,0 +1,232 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+)
+
+// fakeAddrResolver answers PTR and forward lookups from fixed records.
+// Names missing from the maps are NXDOMAIN; names in fail return a server failure.
+type fakeAddrResolver struct {
+	ptr     map[string][]string
+	forward map[string][]string
+	fail    map[string]bool
+}
+
+func (f fakeAddrResolver) LookupAddr(_ context.Context, addr string) ([]string, error) {
+	return f.lookup(addr, f.ptr)
+}
+
+func (f fakeAddrResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	names, err := f.lookup(host, f.forward)
+	var addrs []net.IPAddr
+	for _, n := range names {
+		addrs = append(addrs, net.IPAddr{IP: net.ParseIP(n)})
+	}
+	return addrs, err
+}
+
+func (f fakeAddrResolver) lookup(name string, records map[string][]string) ([]string, error) {
+	if f.fail[name] {
+		return nil, &net.DNSError{Err: "server misbehaving", Name: name, IsTemporary: true}
+	}
+	values, ok := records[name]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return values, nil
+}
+
+func TestReverseDNSLookup(t *testing.T) {
+	tests := []struct {
+		name          string
+		ip            string
+		resolver      fakeAddrResolver
+		wantErr       bool
+		wantNoData    bool
+		wantConfirmed bool
+		wantNames     map[string]bool
+		wantErrorFor  string
+	}{
+		{
+			name: "confirmed",
+			ip:   "192.0.2.1",
+			resolver: fakeAddrResolver{
+				ptr:     map[string][]string{"192.0.2.1": {"host.example.com."}},
+				forward: map[string][]string{"host.example.com.": {"192.0.2.1", "192.0.2.2"}},
+			},
+			wantConfirmed: true,
+			wantNames:     map[string]bool{"host.example.com": true},
+		},
+		{
+			name: "forward mismatch",
+			ip:   "192.0.2.1",
+			resolver: fakeAddrResolver{
+				ptr:     map[string][]string{"192.0.2.1": {"spoofed.example.com."}},
+				forward: map[string][]string{"spoofed.example.com.": {"198.51.100.7"}},
+			},
+			wantNames: map[string]bool{"spoofed.example.com": false},
+		},
+		{
+			name: "one of two names confirms",
+			ip:   "192.0.2.1",
+			resolver: fakeAddrResolver{
+				ptr: map[string][]string{"192.0.2.1": {"a.example.com.", "b.example.com."}},
+				forward: map[string][]string{
+					"a.example.com.": {"198.51.100.7"},
+					"b.example.com.": {"192.0.2.1"},
+				},
+			},
+			wantConfirmed: true,
+			wantNames:     map[string]bool{"a.example.com": false, "b.example.com": true},
+		},
+		{
+			name: "forward name does not exist",
+			ip:   "192.0.2.1",
+			resolver: fakeAddrResolver{
+				ptr: map[string][]string{"192.0.2.1": {"stale.example.com."}},
+			},
+			wantNames:    map[string]bool{"stale.example.com": false},
+			wantErrorFor: "stale.example.com",
+		},
+		{
+			name: "ipv6 in another notation",
+			ip:   "2001:db8::1",
+			resolver: fakeAddrResolver{
+				ptr:     map[string][]string{"2001:db8::1": {"v6.example.com."}},
+				forward: map[string][]string{"v6.example.com.": {"2001:0db8:0000:0000:0000:0000:0000:0001"}},
+			},
+			wantConfirmed: true,
+			wantNames:     map[string]bool{"v6.example.com": true},
+		},
+		{
+			name:       "no PTR",
+			ip:         "192.0.2.1",
+			resolver:   fakeAddrResolver{},
+			wantNoData: true,
+		},
+		{
+			name:     "PTR lookup fails",
+			ip:       "192.0.2.1",
+			resolver: fakeAddrResolver{fail: map[string]bool{"192.0.2.1": true}},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReverseDNS()
+			r.resolver = tt.resolver
+
+			result, err := r.Lookup(context.Background(), tt.ip)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.wantNoData {
+				if result.Raw != nil {
+					t.Errorf("Raw = %+v, want no data", result.Raw)
+				}
+				return
+			}
+
+			resp := result.Raw.(ReverseDNSResponse)
+			if resp.Confirmed != tt.wantConfirmed {
+				t.Errorf("Confirmed = %v, want %v", resp.Confirmed, tt.wantConfirmed)
+			}
+			if len(resp.Hostnames) != len(tt.wantNames) {
+				t.Fatalf("Hostnames = %+v, want %v", resp.Hostnames, tt.wantNames)
+			}
+			for _, h := range resp.Hostnames {
+				want, ok := tt.wantNames[h.Name]
+				if !ok || h.Confirmed != want {
+					t.Errorf("%s confirmed = %v, want %v (listed %v)", h.Name, h.Confirmed, want, ok)
+				}
+				if (h.Error != "") != (h.Name == tt.wantErrorFor) {
+					t.Errorf("%s error = %q", h.Name, h.Error)
+				}
+			}
+		})
+	}
+}
+
+func TestReverseDNSIgnoresHostsFile(t *testing.T) {
+	// The system hosts file maps 127.0.0.1 to localhost; the configured server does not.
+	stub := serveDNS(t, &dnsStub{
+		a:   map[string][]string{"host.example.com": {"192.0.2.1", "2001:db8::1"}},
+		ptr: map[string][]string{"1.2.0.192.in-addr.arpa": {"host.example.com."}},
+	})
+
+	r := NewReverseDNS()
+	settings, err := json.Marshal(ReverseDNSSettings{Resolver: stub.addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Configure(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := r.Lookup(context.Background(), "127.0.0.1")
+	if err != nil || result.Raw != nil {
+		t.Errorf("127.0.0.1: got %+v, %v; want no data from the server", result, err)
+	}
+
+	result, err = r.Lookup(context.Background(), "192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := result.Raw.(ReverseDNSResponse)
+	if !resp.Confirmed || len(resp.Hostnames) != 1 || len(resp.Hostnames[0].Addresses) != 2 {
+		t.Errorf("got %+v, want host.example.com confirmed with its A and AAAA records", resp)
+	}
+}
+
+func TestReadDNSName(t *testing.T) {
+	// "example.com." at 0, "www" + pointer to it at 13, and a pointer loop at 19.
+	msg := []byte("\x07example\x03com\x00\x03www\xc0\x00\xc0\x13")
+
+	tests := []struct {
+		off    int
+		want   string
+		wantOK bool
+	}{
+		{off: 0, want: "example.com.", wantOK: true},
+		{off: 13, want: "www.example.com.", wantOK: true},
+		{off: 12, want: ".", wantOK: true},
+		{off: 19},
+		{off: len(msg)},
+	}
+	for _, tt := range tests {
+		got, ok := readDNSName(msg, tt.off)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("readDNSName at %d = %q %v, want %q %v", tt.off, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	if _, ok := readDNSName([]byte("\x05abc"), 0); ok {
+		t.Error("truncated label was accepted")
+	}
+}
+
+func TestLookupIPErrors(t *testing.T) {
+	stub := newDNSStub(t, map[string][]string{"v4only.test": {"192.0.2.1"}}, nil)
+
+	addrs, err := lookupIP(context.Background(), stub.addr, "v4only.test")
+	if err != nil || len(addrs) != 1 {
+		t.Errorf("v4only.test: got %v, %v; want the A record despite no AAAA", addrs, err)
+	}
+
+	_, err = lookupIP(context.Background(), stub.addr, "missing.test")
+	if !isNotFound(err) {
+		t.Errorf("missing.test: err = %v, want not found", err)
+	}
+	var dnsErr *net.DNSError
+	if !errors.As(err, &dnsErr) || dnsErr.Server != stub.addr {
+		t.Errorf("missing.test: err = %#v, want a DNSError from %s", err, stub.addr)
+	}
+}
@@ -0,0 +1,158 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// ripestatBaseURL is the RIPEstat Data API root.
+const ripestatBaseURL = "https://stat.ripe.net/data/"
+
+// RIPEstatResponse is the routing picture assembled from several RIPEstat data calls.
+type RIPEstatResponse struct {
+	IP         string           `json:"ip"`
+	Prefix     string           `json:"prefix"`
+	Announced  bool             `json:"announced"`
+	Block      string           `json:"block"`
+	Origins    []RIPEstatOrigin `json:"origins"`
+	RIR        string           `json:"rir"`
+	RIRCountry string           `json:"rir_country"`
+	Upstreams  []RIPEstatPeer   `json:"upstreams,omitempty"`
+}
+
+// RIPEstatOrigin is an AS announcing the IP's prefix and the prefix's RPKI status for it.
+type RIPEstatOrigin struct {
+	ASN    int    `json:"asn"`
+	Holder string `json:"holder"`
+	RPKI   string `json:"rpki"`
+}
+
+// RIPEstatPeer is an upstream (left-hand) neighbour of an origin AS.
+type RIPEstatPeer struct {
+	ASN   int `json:"asn"`
+	Power int `json:"power"`
+}
+
+// ripestatEnvelope wraps every RIPEstat data call response.
+type ripestatEnvelope[T any] struct {
+	Status string `json:"status"`
+	Data   T      `json:"data"`
+}
+
+// RIPEstat implements the LookupProvider interface for the RIPEstat Data API.
+type RIPEstat struct {
+	provider.BaseProvider
+}
+
+// NewRIPEstat creates a new RIPEstat provider.
+func NewRIPEstat() *RIPEstat {
+	return &RIPEstat{
+		BaseProvider: provider.BaseProvider{
+			ProviderName: "RIPEstat",
+			ProviderID:   "ripestat",
+		},
+	}
+}
+
+// Lookup resolves the IP's prefix, origins, RIR, upstreams and RPKI validity.
+// A failed RPKI or upstreams call is reported as a warning rather than failing the lookup.
+func (r *RIPEstat) Lookup(ctx context.Context, ip string) (*provider.Result, error) {
+	overview, statusCode, err := ripestatCall[struct {
+		Resource  string `json:"resource"`
+		Announced bool   `json:"announced"`
+		ASNs      []struct {
+			ASN    int    `json:"asn"`
+			Holder string `json:"holder"`
+		} `json:"asns"`
+		Block struct {
+			Resource string `json:"resource"`
+		} `json:"block"`
+	}](ctx, "prefix-overview", url.Values{"resource": {ip}})
+	if err != nil {
+		return provider.NewErrorResult(r, statusCode, err), nil
+	}
+
+	resp := RIPEstatResponse{
+		IP:        ip,
+		Prefix:    overview.Resource,
+		Announced: overview.Announced,
+		Block:     overview.Block.Resource,
+	}
+
+	rir, rirStatus, err := ripestatCall[struct {
+		RIRs []struct {
+			RIR     string `json:"rir"`
+			Country string `json:"country"`
+		} `json:"rirs"`
+	}](ctx, "rir", url.Values{"resource": {ip}})
+	if err != nil {
+		return provider.NewErrorResult(r, rirStatus, err), nil
+	}
+	if len(rir.RIRs) > 0 {
+		resp.RIR = rir.RIRs[0].RIR
+		resp.RIRCountry = rir.RIRs[0].Country
+	}
+
+	result := provider.NewSuccessResult(r, statusCode, nil)
+
+	for _, as := range overview.ASNs {
+		params := url.Values{"resource": {"AS" + strconv.Itoa(as.ASN)}, "prefix": {resp.Prefix}}
+		rpki, _, err := ripestatCall[struct {
+			Status string `json:"status"`
+		}](ctx, "rpki-validation", params)
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("rpki AS%d: %v", as.ASN, err))
+		}
+
+		resp.Origins = append(resp.Origins, RIPEstatOrigin{ASN: as.ASN, Holder: as.Holder, RPKI: rpki.Status})
+	}
+
+	// Upstreams are the left-hand neighbours of the primary origin.
+	if len(resp.Origins) > 0 {
+		params := url.Values{"resource": {"AS" + strconv.Itoa(resp.Origins[0].ASN)}}
+		neighbours, _, err := ripestatCall[struct {
+			Neighbours []struct {
+				ASN   int    `json:"asn"`
+				Type  string `json:"type"`
+				Power int    `json:"power"`
+			} `json:"neighbours"`
+		}](ctx, "asn-neighbours", params)
+		if err != nil {
+			result.Warnings = append(result.Warnings, "upstreams: "+err.Error())
+		}
+		for _, n := range neighbours.Neighbours {
+			if n.Type == "left" {
+				resp.Upstreams = append(resp.Upstreams, RIPEstatPeer{ASN: n.ASN, Power: n.Power})
+			}
+		}
+	}
+
+	result.Raw = resp
+	return result, nil
+}
+
+// ripestatCall fetches a single RIPEstat data call and returns its data payload.
+func ripestatCall[T any](ctx context.Context, name string, params url.Values) (T, int, error) {
+	params.Set("sourceapp", "ip-enrich")
+
+	var envelope ripestatEnvelope[T]
+	statusCode, err := getJSON(ctx, ripestatBaseURL+name+"/data.json?"+params.Encode(), nil, &envelope)
+	if err != nil {
+		return envelope.Data, statusCode, fmt.Errorf("%s: %w", name, err)
+	}
+
+	if envelope.Status != "ok" {
+		err := fmt.Errorf("%s: API returned status=%s", name, envelope.Status)
+		return envelope.Data, statusCode, provider.NewError(provider.ErrorKindAPI, err)
+	}
+
+	return envelope.Data, statusCode, nil
+}
+
+func init() {
+	provider.Register(NewRIPEstat())
+}
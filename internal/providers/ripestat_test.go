@@ -0,0 +1,100 @@
+package providers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+func TestRIPEstatLookupKeepsStatusCode(t *testing.T) {
+	tests := []struct {
+		name       string
+		failOn     string
+		status     int
+		body       string
+		wantStatus int
+		wantKind   provider.ErrorKind
+	}{
+		{name: "first call throttled", failOn: "prefix-overview", status: http.StatusTooManyRequests, wantStatus: 429, wantKind: provider.ErrorKindRateLimited},
+		{name: "later call fails", failOn: "rir", status: http.StatusServiceUnavailable, wantStatus: 503, wantKind: provider.ErrorKindHTTPStatus},
+		{name: "API error status", failOn: "rir", status: http.StatusOK, body: `{"status":"error"}`, wantStatus: 200, wantKind: provider.ErrorKindAPI},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := runAgainst(t, NewRIPEstat(), "193.0.6.139", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/data/"+tt.failOn+"/data.json" {
+					w.WriteHeader(tt.status)
+					fmt.Fprint(w, tt.body)
+					return
+				}
+				fmt.Fprint(w, `{"status":"ok","data":{"resource":"193.0.0.0/21","announced":true,"asns":[]}}`)
+			}))
+
+			if result.StatusCode != tt.wantStatus || result.ErrorKind != tt.wantKind {
+				t.Errorf("got status %d kind %q, want %d %q (%s)", result.StatusCode, result.ErrorKind, tt.wantStatus, tt.wantKind, result.Error)
+			}
+		})
+	}
+}
+
+func TestRIPEstatLookupFollowUpFailures(t *testing.T) {
+	routes := map[string]string{
+		"prefix-overview": `{"status":"ok","data":{"resource":"193.0.0.0/21","announced":true,"block":{"resource":"193.0.0.0/8"},
+			"asns":[{"asn":3333,"holder":"RIPE-NCC-AS"}]}}`,
+		"rir":             `{"status":"ok","data":{"rirs":[{"rir":"RIPE NCC","country":"NL"}]}}`,
+		"rpki-validation": `{"status":"ok","data":{"status":"valid"}}`,
+		"asn-neighbours": `{"status":"ok","data":{"neighbours":[
+			{"asn":1299,"type":"left","power":10},{"asn":64500,"type":"right","power":1}]}}`,
+	}
+
+	tests := []struct {
+		name          string
+		failOn        []string
+		wantRPKI      string
+		wantUpstreams int
+		wantWarnings  []string
+	}{
+		{name: "all calls succeed", wantRPKI: "valid", wantUpstreams: 1},
+		{name: "rpki fails", failOn: []string{"rpki-validation"}, wantUpstreams: 1, wantWarnings: []string{"rpki AS3333"}},
+		{name: "neighbours fail", failOn: []string{"asn-neighbours"}, wantRPKI: "valid", wantWarnings: []string{"upstreams"}},
+		{name: "both fail", failOn: []string{"rpki-validation", "asn-neighbours"}, wantWarnings: []string{"rpki AS3333", "upstreams"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := runAgainst(t, NewRIPEstat(), "193.0.6.139", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/data/"), "/data.json")
+				if slices.Contains(tt.failOn, name) {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				fmt.Fprint(w, routes[name])
+			}))
+
+			if result.Error != "" || result.StatusCode != http.StatusOK {
+				t.Fatalf("got status %d error %q, want the lookup to succeed", result.StatusCode, result.Error)
+			}
+			if len(result.Warnings) != len(tt.wantWarnings) {
+				t.Fatalf("warnings = %q, want %q", result.Warnings, tt.wantWarnings)
+			}
+			for i, prefix := range tt.wantWarnings {
+				if !strings.HasPrefix(result.Warnings[i], prefix+": ") {
+					t.Errorf("warning %d = %q, want prefix %q", i, result.Warnings[i], prefix)
+				}
+			}
+
+			resp := result.Raw.(RIPEstatResponse)
+			if resp.Prefix != "193.0.0.0/21" || resp.RIR != "RIPE NCC" || len(resp.Origins) != 1 || resp.Origins[0].ASN != 3333 {
+				t.Errorf("core data lost: %+v", resp)
+			}
+			if resp.Origins[0].RPKI != tt.wantRPKI || len(resp.Upstreams) != tt.wantUpstreams {
+				t.Errorf("rpki %q upstreams %v, want %q and %d", resp.Origins[0].RPKI, resp.Upstreams, tt.wantRPKI, tt.wantUpstreams)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// maxBannerLength caps the banner kept per service; scanners can return whole HTTP responses.
+const maxBannerLength = 2048
+
+// HostService is an exposed service seen by an internet scanner.
+type HostService struct {
+	Port        int             `json:"port"`
+	Transport   string          `json:"transport,omitempty"`
+	Service     string          `json:"service,omitempty"`
+	Product     string          `json:"product,omitempty"`
+	Version     string          `json:"version,omitempty"`
+	Software    []string        `json:"software,omitempty"`
+	HTTPTitle   string          `json:"http_title,omitempty"`
+	Banner      string          `json:"banner,omitempty"`
+	Certificate *TLSCertificate `json:"certificate,omitempty"`
+	LastSeen    string          `json:"last_seen,omitempty"`
+}
+
+// TLSCertificate is the leaf certificate a service presented.
+type TLSCertificate struct {
+	Subject   string   `json:"subject,omitempty"`
+	Issuer    string   `json:"issuer,omitempty"`
+	Names     []string `json:"names,omitempty"`
+	NotBefore string   `json:"not_before,omitempty"`
+	NotAfter  string   `json:"not_after,omitempty"`
+	SHA256    string   `json:"sha256,omitempty"`
+}
+
+// truncateBanner shortens a banner to maxBannerLength bytes.
+func truncateBanner(banner string) string {
+	if len(banner) <= maxBannerLength {
+		return banner
+	}
+	return strings.ToValidUTF8(banner[:maxBannerLength], "") + "..."
+}
+
+// scannerError is the error body Shodan and Censys return with non-2xx statuses.
+type scannerError struct {
+	Error string `json:"error"`
+}
+
+// scannerStatusError classifies a Shodan or Censys error response, keeping its message.
+func scannerStatusError(statusCode int, body []byte) error {
+	err := provider.NewStatusError(statusCode)
+	var apiErr scannerError
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Error != "" {
+		err.Err = fmt.Errorf("%w: %s", err.Err, apiErr.Error)
+	}
+	return err
+}
@@ -2,7 +2,6 @@ package providers
 
 import (
 	"encoding/json"
-	"fmt"
 
 	"github.com/dalryan/ip-enrich/internal/provider"
 )
@@ -41,12 +40,12 @@ func (s *Shodan) ParseResponse(body []byte, statusCode int) (*provider.Result, e
 	}
 
 	if statusCode != 200 {
-		return provider.NewErrorResult(s, statusCode, fmt.Errorf("unexpected status code: %d", statusCode)), nil
+		return provider.NewErrorResult(s, statusCode, provider.NewStatusError(statusCode)), nil
 	}
 
 	var resp ShodanResponse
 	if err := json.Unmarshal(body, &resp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		return nil, provider.NewParseError(err)
 	}
 
 	result := provider.NewSuccessResult(s, statusCode, resp)
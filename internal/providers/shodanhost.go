@@ -0,0 +1,203 @@
+package providers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// shodanTimeLayout is the format of Shodan's certificate validity dates.
+const shodanTimeLayout = "20060102150405Z"
+
+// ShodanHostResponse is the full Shodan host record with per-port services.
+type ShodanHostResponse struct {
+	IP         string        `json:"ip"`
+	Org        string        `json:"org,omitempty"`
+	ISP        string        `json:"isp,omitempty"`
+	ASN        string        `json:"asn,omitempty"`
+	OS         string        `json:"os,omitempty"`
+	Country    string        `json:"country,omitempty"`
+	City       string        `json:"city,omitempty"`
+	Hostnames  []string      `json:"hostnames"`
+	Ports      []int         `json:"ports"`
+	Tags       []string      `json:"tags"`
+	Vulns      []string      `json:"vulns"`
+	LastUpdate string        `json:"last_update"`
+	Services   []HostService `json:"services"`
+}
+
+// shodanHost is the subset of /shodan/host/{ip} we read.
+type shodanHost struct {
+	IPStr       string   `json:"ip_str"`
+	Org         string   `json:"org"`
+	ISP         string   `json:"isp"`
+	ASN         string   `json:"asn"`
+	OS          *string  `json:"os"`
+	CountryCode string   `json:"country_code"`
+	City        string   `json:"city"`
+	Hostnames   []string `json:"hostnames"`
+	Ports       []int    `json:"ports"`
+	Tags        []string `json:"tags"`
+	Vulns       []string `json:"vulns"`
+	LastUpdate  string   `json:"last_update"`
+	Data        []struct {
+		Port      int      `json:"port"`
+		Transport string   `json:"transport"`
+		Product   string   `json:"product"`
+		Version   string   `json:"version"`
+		CPE23     []string `json:"cpe23"`
+		CPE       []string `json:"cpe"`
+		Data      string   `json:"data"`
+		Timestamp string   `json:"timestamp"`
+		Shodan    struct {
+			Module string `json:"module"`
+		} `json:"_shodan"`
+		HTTP *struct {
+			Title string `json:"title"`
+		} `json:"http"`
+		SSL *struct {
+			Cert struct {
+				Subject     map[string]string `json:"subject"`
+				Issuer      map[string]string `json:"issuer"`
+				Issued      string            `json:"issued"`
+				Expires     string            `json:"expires"`
+				Fingerprint struct {
+					SHA256 string `json:"sha256"`
+				} `json:"fingerprint"`
+			} `json:"cert"`
+		} `json:"ssl"`
+	} `json:"data"`
+}
+
+// ShodanHost implements the Provider interface for the authenticated Shodan host API.
+type ShodanHost struct {
+	provider.BaseProvider
+}
+
+// NewShodanHost creates a new Shodan host provider. It is disabled until an API key is configured.
+func NewShodanHost() *ShodanHost {
+	return &ShodanHost{
+		BaseProvider: provider.BaseProvider{
+			ProviderName: "Shodan Host",
+			ProviderID:   "shodan-host",
+			URLTemplate:  "https://api.shodan.io/shodan/host/{ip}",
+			Auth:         provider.Auth{Scheme: provider.AuthQuery, Name: "key"},
+			// Hosts with many services return full banners for each.
+			MaxBody: 20 * 1024 * 1024,
+		},
+	}
+}
+
+// Configure applies the provider's settings.
+func (s *ShodanHost) Configure(settings json.RawMessage) error {
+	var k provider.KeySettings
+	if err := json.Unmarshal(settings, &k); err != nil {
+		return err
+	}
+	k.Apply(&s.Auth)
+	return nil
+}
+
+// ParseResponse parses the Shodan host response.
+func (s *ShodanHost) ParseResponse(body []byte, statusCode int) (*provider.Result, error) {
+	// Shodan returns 404 for IPs it has no information on
+	if statusCode == http.StatusNotFound {
+		return provider.NewSuccessResult(s, statusCode, nil), nil
+	}
+
+	if statusCode != http.StatusOK {
+		return provider.NewErrorResult(s, statusCode, scannerStatusError(statusCode, body)), nil
+	}
+
+	var host shodanHost
+	if err := json.Unmarshal(body, &host); err != nil {
+		return nil, provider.NewParseError(err)
+	}
+
+	resp := ShodanHostResponse{
+		IP:         host.IPStr,
+		Org:        host.Org,
+		ISP:        host.ISP,
+		ASN:        host.ASN,
+		Country:    host.CountryCode,
+		City:       host.City,
+		Hostnames:  nonNil(host.Hostnames),
+		Ports:      host.Ports,
+		Tags:       nonNil(host.Tags),
+		Vulns:      nonNil(host.Vulns),
+		LastUpdate: host.LastUpdate,
+		Services:   make([]HostService, 0, len(host.Data)),
+	}
+	if resp.Ports == nil {
+		resp.Ports = []int{}
+	}
+	if host.OS != nil {
+		resp.OS = *host.OS
+	}
+
+	for _, d := range host.Data {
+		svc := HostService{
+			Port:      d.Port,
+			Transport: d.Transport,
+			Service:   d.Shodan.Module,
+			Product:   d.Product,
+			Version:   d.Version,
+			Software:  d.CPE23,
+			Banner:    truncateBanner(d.Data),
+			LastSeen:  d.Timestamp,
+		}
+		if len(svc.Software) == 0 {
+			svc.Software = d.CPE
+		}
+		if d.HTTP != nil {
+			svc.HTTPTitle = d.HTTP.Title
+		}
+		if d.SSL != nil {
+			cert := d.SSL.Cert
+			svc.Certificate = &TLSCertificate{
+				Subject:   cert.Subject["CN"],
+				Issuer:    shodanIssuer(cert.Issuer),
+				NotBefore: shodanTime(cert.Issued),
+				NotAfter:  shodanTime(cert.Expires),
+				SHA256:    cert.Fingerprint.SHA256,
+			}
+		}
+		resp.Services = append(resp.Services, svc)
+	}
+
+	return provider.NewSuccessResult(s, statusCode, resp), nil
+}
+
+// shodanIssuer names a certificate issuer by organisation and common name.
+func shodanIssuer(issuer map[string]string) string {
+	switch {
+	case issuer["O"] != "" && issuer["CN"] != "":
+		return issuer["O"] + " (" + issuer["CN"] + ")"
+	case issuer["O"] != "":
+		return issuer["O"]
+	}
+	return issuer["CN"]
+}
+
+// shodanTime converts a certificate date to RFC 3339, or returns it unchanged if it doesn't parse.
+func shodanTime(s string) string {
+	t, err := time.Parse(shodanTimeLayout, s)
+	if err != nil {
+		return s
+	}
+	return t.Format(time.RFC3339)
+}
+
+// nonNil returns s, or an empty slice if s is nil, so it marshals as [] rather than null.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+func init() {
+	provider.Register(NewShodanHost())
+}
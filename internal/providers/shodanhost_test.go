@@ -0,0 +1,93 @@
+package providers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+func TestShodanHostParseResponse(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		wantKind  provider.ErrorKind
+		wantError string
+		wantData  bool
+	}{
+		{
+			name:   "host with services",
+			status: 200,
+			body: `{"ip_str":"192.0.2.1","org":"Example","asn":"AS64500","os":null,"country_code":"NL","ports":[22,443],
+				"data":[
+					{"port":22,"transport":"tcp","product":"OpenSSH","version":"9.6","cpe":["cpe:/a:openbsd:openssh:9.6"],"data":"SSH-2.0-OpenSSH_9.6","_shodan":{"module":"ssh"}},
+					{"port":443,"transport":"tcp","cpe23":["cpe:2.3:a:nginx:nginx"],"_shodan":{"module":"https"},"http":{"title":"Welcome"},
+						"ssl":{"cert":{"subject":{"CN":"example.com"},"issuer":{"O":"Let's Encrypt","CN":"R3"},
+							"issued":"20240101000000Z","expires":"20240401000000Z","fingerprint":{"sha256":"ab12"}}}}]}`,
+			wantData: true,
+		},
+		{name: "no information", status: 404, body: `{"error":"No information available for that IP."}`},
+		{name: "invalid key", status: 401, body: `{"error":"Please provide a valid API key."}`, wantKind: provider.ErrorKindAuth, wantError: "Please provide a valid API key."},
+		{name: "out of credits", status: 403, body: `{"error":"Access denied (403 Forbidden)"}`, wantKind: provider.ErrorKindAuth, wantError: "Access denied"},
+		{name: "html error page", status: 502, body: `<html>Bad Gateway</html>`, wantKind: provider.ErrorKindHTTPStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewShodanHost().ParseResponse([]byte(tt.body), tt.status)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.ErrorKind != tt.wantKind {
+				t.Fatalf("ErrorKind = %q, want %q (%s)", result.ErrorKind, tt.wantKind, result.Error)
+			}
+			if !strings.Contains(result.Error, tt.wantError) {
+				t.Errorf("Error = %q, want it to contain %q", result.Error, tt.wantError)
+			}
+			// Scan data describes exposure, not reputation.
+			if result.Verdict != "" {
+				t.Errorf("Verdict = %q, want none", result.Verdict)
+			}
+			if (result.Raw != nil) != tt.wantData {
+				t.Fatalf("Raw = %+v, want data %v", result.Raw, tt.wantData)
+			}
+			if !tt.wantData {
+				return
+			}
+
+			resp := result.Raw.(ShodanHostResponse)
+			if resp.OS != "" || len(resp.Hostnames) != 0 || resp.Hostnames == nil || len(resp.Services) != 2 {
+				t.Fatalf("unexpected host: %+v", resp)
+			}
+			ssh, https := resp.Services[0], resp.Services[1]
+			if ssh.Service != "ssh" || ssh.Product != "OpenSSH" || len(ssh.Software) != 1 || ssh.Banner != "SSH-2.0-OpenSSH_9.6" {
+				t.Errorf("ssh service = %+v", ssh)
+			}
+			cert := https.Certificate
+			if https.HTTPTitle != "Welcome" || https.Software[0] != "cpe:2.3:a:nginx:nginx" || cert == nil {
+				t.Fatalf("https service = %+v", https)
+			}
+			if cert.Subject != "example.com" || cert.Issuer != "Let's Encrypt (R3)" || cert.NotAfter != "2024-04-01T00:00:00Z" {
+				t.Errorf("certificate = %+v", cert)
+			}
+		})
+	}
+}
+
+func TestShodanIssuer(t *testing.T) {
+	tests := []struct {
+		issuer map[string]string
+		want   string
+	}{
+		{map[string]string{"O": "Let's Encrypt", "CN": "R3"}, "Let's Encrypt (R3)"},
+		{map[string]string{"O": "Example CA"}, "Example CA"},
+		{map[string]string{"CN": "self-signed"}, "self-signed"},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := shodanIssuer(tt.issuer); got != tt.want {
+			t.Errorf("shodanIssuer(%v) = %q, want %q", tt.issuer, got, tt.want)
+		}
+	}
+}
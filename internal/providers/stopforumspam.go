@@ -3,13 +3,15 @@ package providers
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/dalryan/ip-enrich/internal/provider"
 )
 
 // StopForumSpamResponse represents the response from Stop Forum Spam API.
 type StopForumSpamResponse struct {
-	Success int `json:"success"`
+	Success int    `json:"success"`
+	Error   string `json:"error,omitempty"`
 	IP      struct {
 		Value            string  `json:"value"`
 		Frequency        int     `json:"frequency"`
@@ -41,19 +43,27 @@ func NewStopForumSpam() *StopForumSpam {
 // ParseResponse parses the Stop Forum Spam API response into normalized fields.
 func (s *StopForumSpam) ParseResponse(body []byte, statusCode int) (*provider.Result, error) {
 	if statusCode != 200 {
-		return provider.NewErrorResult(s, statusCode, fmt.Errorf("unexpected status code: %d", statusCode)), nil
+		return provider.NewErrorResult(s, statusCode, provider.NewStatusError(statusCode)), nil
 	}
 
 	var resp StopForumSpamResponse
 	if err := json.Unmarshal(body, &resp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		return nil, provider.NewParseError(err)
 	}
 
 	if resp.Success != 1 {
-		return provider.NewErrorResult(s, statusCode, fmt.Errorf("API returned success=%d", resp.Success)), nil
+		err := provider.NewError(provider.ErrorKindAPI, fmt.Errorf("API returned success=%d: %s", resp.Success, resp.Error))
+		// The daily query limit is reported in the body.
+		if strings.Contains(strings.ToLower(resp.Error), "limit") {
+			err.Kind = provider.ErrorKindQuota
+		}
+		return provider.NewErrorResult(s, statusCode, err), nil
 	}
 
 	result := provider.NewSuccessResult(s, statusCode, resp)
+	if resp.IP.Appears > 0 {
+		result.Verdict = provider.VerdictSuspicious
+	}
 	return result, nil
 }
 
@@ -0,0 +1,36 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+func TestStopForumSpamParseResponse(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantKind    provider.ErrorKind
+		wantVerdict provider.Verdict
+	}{
+		{name: "listed", body: `{"success":1,"ip":{"value":"192.0.2.1","appears":1,"frequency":12}}`, wantVerdict: provider.VerdictSuspicious},
+		{name: "not listed", body: `{"success":1,"ip":{"value":"192.0.2.1","appears":0}}`},
+		{name: "invalid ip", body: `{"success":0,"error":"invalid ip"}`, wantKind: provider.ErrorKindAPI},
+		{name: "daily limit", body: `{"success":0,"error":"rate limit exceeded"}`, wantKind: provider.ErrorKindQuota},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewStopForumSpam().ParseResponse([]byte(tt.body), 200)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.ErrorKind != tt.wantKind {
+				t.Errorf("ErrorKind = %q, want %q (%s)", result.ErrorKind, tt.wantKind, result.Error)
+			}
+			if result.Verdict != tt.wantVerdict {
+				t.Errorf("Verdict = %q, want %q", result.Verdict, tt.wantVerdict)
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package providers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"strings"
+
+	"github.com/dalryan/ip-enrich/internal/iptrie"
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// Threat feed file formats.
+const (
+	feedFormatText = "text"
+	feedFormatCSV  = "csv"
+	feedFormatJSON = "json"
+)
+
+// ThreatFeed declares a local IOC list.
+type ThreatFeed struct {
+	Name string `json:"name"`
+
+	// Path is the feed file, relative to the data directory unless absolute.
+	Path string   `json:"path"`
+	Tags []string `json:"tags,omitempty"`
+
+	// Format is "text" (default), "csv" or "json".
+	Format string `json:"format,omitempty"`
+
+	// Column is the zero-based CSV column holding the IP or CIDR.
+	Column int `json:"column,omitempty"`
+
+	// Field is the JSON object key holding the IP or CIDR (default "ip").
+	// JSON feeds may be an array or newline-delimited; bare strings are also accepted.
+	Field string `json:"field,omitempty"`
+
+	// Verdict is reported when the IP matches this feed (default "suspicious").
+	Verdict provider.Verdict `json:"verdict,omitempty"`
+}
+
+// ThreatFeedSettings are the config file settings for the threat feed provider.
+type ThreatFeedSettings struct {
+	Feeds []ThreatFeed `json:"feeds"`
+}
+
+// ThreatFeedResponse lists every feed the IP appears in.
+type ThreatFeedResponse struct {
+	IP      string            `json:"ip"`
+	Matches []ThreatFeedMatch `json:"matches"`
+	Checked int               `json:"checked"`
+	Errors  map[string]string `json:"errors,omitempty"`
+}
+
+// ThreatFeedMatch is a single feed entry that covers the IP.
+type ThreatFeedMatch struct {
+	Feed  string   `json:"feed"`
+	Entry string   `json:"entry"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+// loadedFeed pairs a feed declaration with its reloadable entries.
+type loadedFeed struct {
+	ThreatFeed
+	entries *fileCache[*iptrie.Trie[string]]
+}
+
+// LocalThreatFeeds implements the LookupProvider interface for local blocklists and IOC files.
+type LocalThreatFeeds struct {
+	provider.BaseProvider
+	feeds []*loadedFeed
+}
+
+// NewLocalThreatFeeds creates a new threat feed provider. It is disabled until feeds are configured.
+func NewLocalThreatFeeds() *LocalThreatFeeds {
+	return &LocalThreatFeeds{
+		BaseProvider: provider.BaseProvider{
+			ProviderName: "Local Threat Feeds",
+			ProviderID:   "threatfeed",
+		},
+	}
+}
+
+// Configure applies the provider's settings.
+func (t *LocalThreatFeeds) Configure(settings json.RawMessage) error {
+	var s ThreatFeedSettings
+	if err := json.Unmarshal(settings, &s); err != nil {
+		return err
+	}
+
+	feeds := make([]*loadedFeed, 0, len(s.Feeds))
+	for _, f := range s.Feeds {
+		if f.Name == "" || f.Path == "" {
+			return errors.New("every feed needs a name and a path")
+		}
+		if f.Format == "" {
+			f.Format = feedFormatText
+		}
+		if f.Field == "" {
+			f.Field = "ip"
+		}
+		if f.Verdict == "" {
+			f.Verdict = provider.VerdictSuspicious
+		}
+
+		switch f.Format {
+		case feedFormatText, feedFormatCSV, feedFormatJSON:
+		default:
+			return fmt.Errorf("feed %s: unknown format %q (supported: text, csv, json)", f.Name, f.Format)
+		}
+
+		feeds = append(feeds, &loadedFeed{
+			ThreatFeed: f,
+			entries:    newFileCache(provider.DataPath(f.Path), f.load),
+		})
+	}
+
+	t.feeds = feeds
+	return nil
+}
+
+// Enabled reports whether any feeds are configured.
+func (t *LocalThreatFeeds) Enabled() bool {
+	return len(t.feeds) > 0
+}
+
+// Lookup reports every feed entry covering the IP. Feeds are reloaded when their files change.
+func (t *LocalThreatFeeds) Lookup(_ context.Context, ip string) (*provider.Result, error) {
+	if !t.Enabled() {
+		return nil, errors.New("no threat feeds configured")
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IP address: %s", ip)
+	}
+
+	resp := ThreatFeedResponse{IP: ip, Matches: []ThreatFeedMatch{}}
+	var verdict provider.Verdict
+
+	var warnings []string
+	for _, f := range t.feeds {
+		entries, err := f.entries.get()
+		if err != nil {
+			if resp.Errors == nil {
+				resp.Errors = make(map[string]string)
+			}
+			resp.Errors[f.Name] = err.Error()
+			warnings = append(warnings, f.Name+": "+err.Error())
+			continue
+		}
+		resp.Checked++
+
+		for _, entry := range entries.Match(addr) {
+			resp.Matches = append(resp.Matches, ThreatFeedMatch{Feed: f.Name, Entry: entry, Tags: f.Tags})
+			verdict = provider.MaxVerdict(verdict, f.Verdict)
+		}
+	}
+
+	if resp.Checked == 0 {
+		return nil, fmt.Errorf("no threat feeds could be loaded: %v", resp.Errors)
+	}
+
+	result := provider.NewSuccessResult(t, 0, resp)
+	result.Verdict = verdict
+	result.Warnings = warnings
+	return result, nil
+}
+
+// load parses the feed file into a prefix trie of its entries.
+func (f ThreatFeed) load(path string) (*iptrie.Trie[string], error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []string
+	switch f.Format {
+	case feedFormatCSV:
+		entries, err = parseCSVFeed(data, f.Column)
+	case feedFormatJSON:
+		entries, err = parseJSONFeed(data, f.Field)
+	default:
+		entries = parseTextFeed(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse feed %s: %w", f.Name, err)
+	}
+
+	trie := iptrie.New[string]()
+	for _, entry := range entries {
+		prefix, err := iptrie.ParsePrefix(entry)
+		if err != nil {
+			// Feeds mix in hostnames and junk lines; skip anything that isn't an IP or CIDR.
+			continue
+		}
+		trie.Insert(prefix, entry)
+	}
+	return trie, nil
+}
+
+// parseTextFeed reads one entry per line, ignoring "#" and ";" comments
+// (e.g. "1.10.16.0/20 ; SBL256894" in Spamhaus DROP).
+func parseTextFeed(data []byte) []string {
+	var entries []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexAny(line, "#;"); i >= 0 {
+			line = line[:i]
+		}
+		if fields := strings.Fields(line); len(fields) > 0 {
+			entries = append(entries, fields[0])
+		}
+	}
+	return entries
+}
+
+// parseCSVFeed reads the given column of every record, skipping "#" comment lines.
+func parseCSVFeed(data []byte, column int) ([]string, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	var entries []string
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if column < len(record) {
+			entries = append(entries, strings.TrimSpace(record[column]))
+		}
+	}
+	return entries, nil
+}
+
+// parseJSONFeed reads a JSON array or newline-delimited JSON of strings or objects.
+func parseJSONFeed(data []byte, field string) ([]string, error) {
+	var values []json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		values = nil
+		dec := json.NewDecoder(bytes.NewReader(data))
+		for {
+			var v json.RawMessage
+			if err := dec.Decode(&v); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+	}
+
+	var entries []string
+	for _, v := range values {
+		var s string
+		if err := json.Unmarshal(v, &s); err == nil {
+			entries = append(entries, s)
+			continue
+		}
+
+		var obj map[string]any
+		if err := json.Unmarshal(v, &obj); err != nil {
+			continue
+		}
+		if s, ok := obj[field].(string); ok {
+			entries = append(entries, s)
+		}
+	}
+	return entries, nil
+}
+
+func init() {
+	provider.Register(NewLocalThreatFeeds())
+}
@@ -0,0 +1,162 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// newTestThreatFeeds writes each feed's contents to a temporary file and configures a provider for them.
+func newTestThreatFeeds(t *testing.T, feeds []ThreatFeed, contents []string) *LocalThreatFeeds {
+	t.Helper()
+
+	dir := t.TempDir()
+	for i := range feeds {
+		feeds[i].Path = filepath.Join(dir, feeds[i].Name)
+		if err := os.WriteFile(feeds[i].Path, []byte(contents[i]), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	settings, err := json.Marshal(ThreatFeedSettings{Feeds: feeds})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewLocalThreatFeeds()
+	if err := p.Configure(settings); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestThreatFeedFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		feed    ThreatFeed
+		content string
+		ip      string
+		want    []string
+	}{
+		{
+			name:    "text with comments and junk",
+			feed:    ThreatFeed{Format: feedFormatText},
+			content: "# header\n\n  \n1.10.16.0/20 ; SBL256894\nexample.com\n300.1.1.1\n192.0.2.0/33\n1.10.16.5 # host\n",
+			ip:      "1.10.16.5",
+			want:    []string{"1.10.16.0/20", "1.10.16.5"},
+		},
+		{
+			name:    "text overlapping prefixes",
+			feed:    ThreatFeed{},
+			content: "10.0.0.0/8\n10.1.0.0/16\n10.1.2.0/24\n10.2.0.0/16\n",
+			ip:      "10.1.2.3",
+			want:    []string{"10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24"},
+		},
+		{
+			name:    "text IPv4-mapped lookup",
+			feed:    ThreatFeed{},
+			content: "198.51.100.0/24\n",
+			ip:      "::ffff:198.51.100.7",
+			want:    []string{"198.51.100.0/24"},
+		},
+		{
+			name:    "csv column with short and junk rows",
+			feed:    ThreatFeed{Format: feedFormatCSV, Column: 1},
+			content: "# first_seen,ip,port\n2024-01-01,192.0.2.1,443\nshort\n2024-01-02, 192.0.2.0/24 ,80\n2024-01-03,not-an-ip,22\n",
+			ip:      "192.0.2.1",
+			want:    []string{"192.0.2.0/24", "192.0.2.1"},
+		},
+		{
+			name:    "json array of strings and objects",
+			feed:    ThreatFeed{Format: feedFormatJSON, Field: "ip_address"},
+			content: `["192.0.2.1", {"ip_address": "192.0.2.0/28"}, {"other": "192.0.2.1"}, 42, {"ip_address": 7}]`,
+			ip:      "192.0.2.1",
+			want:    []string{"192.0.2.0/28", "192.0.2.1"},
+		},
+		{
+			name:    "ndjson",
+			feed:    ThreatFeed{Format: feedFormatJSON},
+			content: "{\"ip\": \"2001:db8::/32\"}\n{\"ip\": \"2001:db8::1\"}\n{\"ip\": \"garbage\"}\n",
+			ip:      "2001:db8::1",
+			want:    []string{"2001:db8::/32", "2001:db8::1"},
+		},
+		{
+			name:    "no match",
+			feed:    ThreatFeed{},
+			content: "192.0.2.0/24\n",
+			ip:      "198.51.100.1",
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.feed.Name = "feed"
+			p := newTestThreatFeeds(t, []ThreatFeed{tt.feed}, []string{tt.content})
+
+			result, err := p.Lookup(context.Background(), tt.ip)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp := result.Raw.(ThreatFeedResponse)
+
+			var got []string
+			for _, m := range resp.Matches {
+				got = append(got, m.Entry)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("matches = %v, want %v", got, tt.want)
+			}
+
+			wantVerdict := provider.Verdict("")
+			if len(tt.want) > 0 {
+				wantVerdict = provider.VerdictSuspicious
+			}
+			if result.Verdict != wantVerdict {
+				t.Errorf("verdict = %q, want %q", result.Verdict, wantVerdict)
+			}
+			if len(result.Warnings) != 0 {
+				t.Errorf("warnings = %q", result.Warnings)
+			}
+		})
+	}
+}
+
+func TestThreatFeedVerdictAndErrors(t *testing.T) {
+	p := newTestThreatFeeds(t, []ThreatFeed{
+		{Name: "c2", Verdict: provider.VerdictMalicious, Tags: []string{"c2"}},
+		{Name: "scanners"},
+		{Name: "broken", Format: feedFormatJSON},
+	}, []string{"192.0.2.0/24\n", "192.0.2.1\n", "{not json"})
+
+	result, err := p.Lookup(context.Background(), "192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := result.Raw.(ThreatFeedResponse)
+
+	if result.Verdict != provider.VerdictMalicious {
+		t.Errorf("verdict = %q, want the most severe feed verdict", result.Verdict)
+	}
+	if resp.Checked != 2 || len(resp.Matches) != 2 || resp.Matches[0].Tags[0] != "c2" {
+		t.Errorf("got %+v", resp)
+	}
+	if _, ok := resp.Errors["broken"]; !ok {
+		t.Errorf("errors = %v, want the broken feed reported", resp.Errors)
+	}
+	if len(result.Warnings) != 1 || !strings.HasPrefix(result.Warnings[0], "broken: ") {
+		t.Errorf("warnings = %q, want the broken feed", result.Warnings)
+	}
+}
+
+func TestThreatFeedAllUnreadable(t *testing.T) {
+	p := newTestThreatFeeds(t, []ThreatFeed{{Name: "broken", Format: feedFormatJSON}}, []string{"[1,"})
+	if _, err := p.Lookup(context.Background(), "192.0.2.1"); err == nil {
+		t.Error("expected an error when no feed could be loaded")
+	}
+}
@@ -0,0 +1,190 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// threatFoxMaliciousConfidence is the IOC confidence level at which a match is malicious.
+const threatFoxMaliciousConfidence = 75
+
+// ThreatFoxResponse lists the ThreatFox IOCs for the IP.
+type ThreatFoxResponse struct {
+	IOCs            []ThreatFoxIOC `json:"iocs"`
+	MalwareFamilies []string       `json:"malware_families"`
+	ThreatTypes     []string       `json:"threat_types"`
+}
+
+// ThreatFoxIOC is a single ThreatFox indicator matching the IP.
+type ThreatFoxIOC struct {
+	ID           string   `json:"id"`
+	IOC          string   `json:"ioc"`
+	IOCType      string   `json:"ioc_type"`
+	ThreatType   string   `json:"threat_type"`
+	Malware      string   `json:"malware"`
+	MalwareAlias string   `json:"malware_alias,omitempty"`
+	Confidence   int      `json:"confidence"`
+	FirstSeen    string   `json:"first_seen"`
+	LastSeen     string   `json:"last_seen,omitempty"`
+	Reference    string   `json:"reference,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+}
+
+// threatFoxEnvelope is the ThreatFox API response. Data is a message string unless
+// query_status is "ok".
+type threatFoxEnvelope struct {
+	QueryStatus string          `json:"query_status"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// threatFoxIOC is an IOC as returned by search_ioc.
+type threatFoxIOC struct {
+	ID               json.Number `json:"id"`
+	IOC              string      `json:"ioc"`
+	IOCType          string      `json:"ioc_type"`
+	ThreatType       string      `json:"threat_type"`
+	MalwarePrintable string      `json:"malware_printable"`
+	MalwareAlias     string      `json:"malware_alias"`
+	ConfidenceLevel  int         `json:"confidence_level"`
+	FirstSeen        string      `json:"first_seen"`
+	LastSeen         string      `json:"last_seen"`
+	Reference        string      `json:"reference"`
+	Tags             []string    `json:"tags"`
+}
+
+// ThreatFox implements the LookupProvider interface for the abuse.ch ThreatFox IOC search.
+type ThreatFox struct {
+	provider.BaseProvider
+}
+
+// NewThreatFox creates a new ThreatFox provider. It is disabled until an abuse.ch Auth-Key is configured.
+func NewThreatFox() *ThreatFox {
+	return &ThreatFox{
+		BaseProvider: provider.BaseProvider{
+			ProviderName: "ThreatFox",
+			ProviderID:   "threatfox",
+			URLTemplate:  "https://threatfox-api.abuse.ch/api/v1/",
+			BodyTemplate: `{"query": "search_ioc", "search_term": "{ip}", "exact_match": false}`,
+			Auth:         provider.Auth{Scheme: provider.AuthHeader, Name: "Auth-Key"},
+		},
+	}
+}
+
+// Configure applies the provider's settings.
+func (t *ThreatFox) Configure(settings json.RawMessage) error {
+	var s provider.KeySettings
+	if err := json.Unmarshal(settings, &s); err != nil {
+		return err
+	}
+	s.Apply(&t.Auth)
+	return nil
+}
+
+// Lookup searches ThreatFox for IOCs on the IP. search_ioc matches substrings, so results
+// are filtered to IOCs that are the IP itself or the IP with a port.
+func (t *ThreatFox) Lookup(ctx context.Context, ip string) (*provider.Result, error) {
+	req, err := t.BuildRequest(ctx, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	httpResp, err := provider.Fetch(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	statusCode := httpResp.StatusCode
+	if statusCode != http.StatusOK {
+		return provider.NewErrorResult(t, statusCode, provider.NewStatusError(statusCode)), nil
+	}
+
+	var envelope threatFoxEnvelope
+	if err := json.Unmarshal(httpResp.Body, &envelope); err != nil {
+		return provider.NewErrorResult(t, statusCode, provider.NewParseError(err)), nil
+	}
+
+	switch envelope.QueryStatus {
+	case "ok":
+	case "no_result":
+		return provider.NewSuccessResult(t, statusCode, nil), nil
+	default:
+		return provider.NewErrorResult(t, statusCode, abuseCHError(envelope.QueryStatus, envelope.Data)), nil
+	}
+
+	var all []threatFoxIOC
+	if err := json.Unmarshal(envelope.Data, &all); err != nil {
+		return provider.NewErrorResult(t, statusCode, provider.NewParseError(err)), nil
+	}
+
+	iocs := slices.DeleteFunc(all, func(i threatFoxIOC) bool { return !matchesIOC(i.IOC, ip) })
+	if len(iocs) == 0 {
+		return provider.NewSuccessResult(t, statusCode, nil), nil
+	}
+
+	resp := ThreatFoxResponse{
+		IOCs:            make([]ThreatFoxIOC, 0, len(iocs)),
+		MalwareFamilies: []string{},
+		ThreatTypes:     []string{},
+	}
+	verdict := provider.VerdictSuspicious
+	for _, i := range iocs {
+		resp.IOCs = append(resp.IOCs, ThreatFoxIOC{
+			ID:           i.ID.String(),
+			IOC:          i.IOC,
+			IOCType:      i.IOCType,
+			ThreatType:   i.ThreatType,
+			Malware:      i.MalwarePrintable,
+			MalwareAlias: i.MalwareAlias,
+			Confidence:   i.ConfidenceLevel,
+			FirstSeen:    i.FirstSeen,
+			LastSeen:     i.LastSeen,
+			Reference:    i.Reference,
+			Tags:         i.Tags,
+		})
+		resp.MalwareFamilies = appendUnique(resp.MalwareFamilies, i.MalwarePrintable)
+		resp.ThreatTypes = appendUnique(resp.ThreatTypes, i.ThreatType)
+		if i.ConfidenceLevel >= threatFoxMaliciousConfidence {
+			verdict = provider.VerdictMalicious
+		}
+	}
+
+	result := provider.NewSuccessResult(t, statusCode, resp)
+	result.Verdict = verdict
+	return result, nil
+}
+
+// matchesIOC reports whether an ip or ip:port IOC refers to ip.
+func matchesIOC(ioc, ip string) bool {
+	if host, _, err := net.SplitHostPort(ioc); err == nil {
+		ioc = host
+	}
+	a, errA := netip.ParseAddr(ioc)
+	b, errB := netip.ParseAddr(ip)
+	return errA == nil && errB == nil && a.Unmap() == b.Unmap()
+}
+
+// abuseCHError describes a failed abuse.ch query. The APIs report failures such as
+// a missing or invalid key in query_status with HTTP 200.
+func abuseCHError(status string, data json.RawMessage) error {
+	err := fmt.Errorf("API returned query_status=%s", status)
+	var msg string
+	if json.Unmarshal(data, &msg) == nil && msg != "" {
+		err = fmt.Errorf("%w: %s", err, msg)
+	}
+
+	switch status {
+	case "unknown_auth_key", "missing_auth_key":
+		return provider.NewError(provider.ErrorKindAuth, err)
+	}
+	return provider.NewError(provider.ErrorKindAPI, err)
+}
+
+func init() {
+	provider.Register(NewThreatFox())
+}
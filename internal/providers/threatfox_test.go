@@ -0,0 +1,105 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+func TestThreatFoxLookup(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantKind    provider.ErrorKind
+		wantError   string
+		wantVerdict provider.Verdict
+		wantIOCs    []string
+	}{
+		{
+			name: "high confidence",
+			body: `{"query_status":"ok","data":[
+				{"id":"1","ioc":"192.0.2.1:443","threat_type":"botnet_cc","malware_printable":"Cobalt Strike","confidence_level":100},
+				{"id":2,"ioc":"192.0.2.10:80","threat_type":"botnet_cc","malware_printable":"Other","confidence_level":100},
+				{"id":"3","ioc":"192.0.2.1","threat_type":"payload_delivery","malware_printable":"Cobalt Strike","confidence_level":50}]}`,
+			wantVerdict: provider.VerdictMalicious,
+			wantIOCs:    []string{"192.0.2.1:443", "192.0.2.1"},
+		},
+		{
+			name:        "low confidence",
+			body:        `{"query_status":"ok","data":[{"id":"4","ioc":"192.0.2.1:8080","threat_type":"botnet_cc","confidence_level":74}]}`,
+			wantVerdict: provider.VerdictSuspicious,
+			wantIOCs:    []string{"192.0.2.1:8080"},
+		},
+		{name: "only substring matches", body: `{"query_status":"ok","data":[{"id":"5","ioc":"192.0.2.11:443","confidence_level":100}]}`},
+		{name: "no result", body: `{"query_status":"no_result","data":"Your search did not yield any results"}`},
+		{
+			name:      "unknown key",
+			body:      `{"query_status":"unknown_auth_key","data":"The Auth-Key you provided is unknown"}`,
+			wantKind:  provider.ErrorKindAuth,
+			wantError: "query_status=unknown_auth_key: The Auth-Key you provided is unknown",
+		},
+		{name: "illegal search term", body: `{"query_status":"illegal_search_term"}`, wantKind: provider.ErrorKindAPI, wantError: "query_status=illegal_search_term"},
+		{name: "http error", status: http.StatusUnauthorized, wantKind: provider.ErrorKindAuth},
+		{name: "malformed", body: `{"query_status":"ok","data":{}}`, wantKind: provider.ErrorKindParse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tf := NewThreatFox()
+			if err := tf.Configure([]byte(`{"api_key":"test"}`)); err != nil {
+				t.Fatal(err)
+			}
+
+			result := runAgainst(t, tf, "192.0.2.1", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var query struct {
+					Query      string `json:"query"`
+					SearchTerm string `json:"search_term"`
+				}
+				body, _ := io.ReadAll(r.Body)
+				if err := json.Unmarshal(body, &query); err != nil || query.Query != "search_ioc" || query.SearchTerm != "192.0.2.1" {
+					t.Errorf("unexpected query %s", body)
+				}
+				if r.Header.Get("Auth-Key") != "test" {
+					t.Errorf("Auth-Key = %q, want the configured key", r.Header.Get("Auth-Key"))
+				}
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				fmt.Fprint(w, tt.body)
+			}))
+
+			if result.ErrorKind != tt.wantKind {
+				t.Fatalf("ErrorKind = %q, want %q (%s)", result.ErrorKind, tt.wantKind, result.Error)
+			}
+			if !strings.Contains(result.Error, tt.wantError) {
+				t.Errorf("Error = %q, want it to contain %q", result.Error, tt.wantError)
+			}
+			if result.Verdict != tt.wantVerdict {
+				t.Errorf("Verdict = %q, want %q", result.Verdict, tt.wantVerdict)
+			}
+			if tt.wantKind != "" {
+				return
+			}
+
+			if len(tt.wantIOCs) == 0 {
+				if result.Raw != nil {
+					t.Errorf("Raw = %+v, want no data", result.Raw)
+				}
+				return
+			}
+			var got []string
+			for _, ioc := range result.Raw.(ThreatFoxResponse).IOCs {
+				got = append(got, ioc.IOC)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.wantIOCs, ",") {
+				t.Errorf("IOCs = %q, want %q", got, tt.wantIOCs)
+			}
+		})
+	}
+}
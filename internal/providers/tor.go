@@ -0,0 +1,235 @@
+package providers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dalryan/ip-enrich/internal/config"
+	"github.com/dalryan/ip-enrich/internal/feeds"
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+const (
+	// torExitListFeed is the managed feed holding the exit list, and its file in the data directory.
+	torExitListFeed = "tor-exit-addresses"
+
+	// torExitListURL is the Tor Project's exit list with per-relay timestamps.
+	torExitListURL = "https://check.torproject.org/exit-addresses"
+
+	// torTimeLayout is the timestamp format used in exit-addresses (UTC).
+	torTimeLayout = "2006-01-02 15:04:05"
+)
+
+// TorExitResponse reports whether the IP is a Tor exit relay.
+type TorExitResponse struct {
+	IP           string   `json:"ip"`
+	IsExit       bool     `json:"is_exit"`
+	LastSeen     string   `json:"last_seen,omitempty"`
+	Fingerprints []string `json:"fingerprints,omitempty"`
+	Source       string   `json:"source"`
+	ListUpdated  string   `json:"list_updated"`
+}
+
+// TorSettings are the config file settings for the Tor exit provider.
+type TorSettings struct {
+	// Path is a local exit list (exit-addresses or one IP per line), relative to the
+	// data directory unless absolute. It defaults to the managed tor-exit-addresses feed.
+	Path string `json:"path"`
+}
+
+// torExit is everything the exit list says about one address.
+type torExit struct {
+	fingerprints []string
+	lastSeen     time.Time
+}
+
+// torExitList maps exit addresses to their relays.
+type torExitList struct {
+	exits   map[netip.Addr]*torExit
+	updated time.Time
+}
+
+// TorExit implements the LookupProvider interface for the Tor Project's exit list.
+// It only reads the list; "ip-enrich feeds update" downloads it.
+type TorExit struct {
+	provider.BaseProvider
+	path string
+
+	mu   sync.Mutex
+	list *fileCache[*torExitList]
+}
+
+// NewTorExit creates a new Tor exit provider reading the managed exit list feed.
+func NewTorExit() *TorExit {
+	return &TorExit{
+		BaseProvider: provider.BaseProvider{
+			ProviderName: "Tor Exit List",
+			ProviderID:   "tor",
+		},
+		path: torExitListFeed,
+	}
+}
+
+// Configure applies the provider's settings.
+func (t *TorExit) Configure(settings json.RawMessage) error {
+	var s TorSettings
+	if err := json.Unmarshal(settings, &s); err != nil {
+		return err
+	}
+
+	t.path = torExitListFeed
+	if s.Path != "" {
+		t.path = s.Path
+	}
+	return nil
+}
+
+// Enabled reports whether the exit list file exists, so the provider stays
+// off by default until "ip-enrich feeds update" has downloaded it.
+func (t *TorExit) Enabled() bool {
+	_, err := os.Stat(provider.DataPath(t.path))
+	return err == nil
+}
+
+// Lookup checks the IP against the exit list.
+func (t *TorExit) Lookup(_ context.Context, ip string) (*provider.Result, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IP address: %s", ip)
+	}
+
+	list, source, err := t.exitList()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := TorExitResponse{IP: ip, Source: source, ListUpdated: list.updated.Format(time.RFC3339)}
+	if exit, ok := list.exits[addr.Unmap()]; ok {
+		resp.IsExit = true
+		resp.Fingerprints = exit.fingerprints
+		if !exit.lastSeen.IsZero() {
+			resp.LastSeen = exit.lastSeen.Format(time.RFC3339)
+		}
+	}
+
+	return provider.NewSuccessResult(t, 0, resp), nil
+}
+
+// exitList returns the exit list and the file it came from, reloading it when the file changes.
+// The path is resolved on every call because the data directory is set after registration.
+func (t *TorExit) exitList() (*torExitList, string, error) {
+	path := provider.DataPath(t.path)
+
+	t.mu.Lock()
+	if t.list == nil || t.list.path != path {
+		t.list = newFileCache(path, loadTorExitList)
+	}
+	cache := t.list
+	t.mu.Unlock()
+
+	list, err := cache.get()
+	if errors.Is(err, fs.ErrNotExist) && t.path == torExitListFeed {
+		return nil, path, fmt.Errorf("tor exit list not downloaded, run \"ip-enrich feeds update %s\": %w", torExitListFeed, err)
+	}
+	return list, path, err
+}
+
+// loadTorExitList reads an exit list file. Its modification time is taken as the list's age.
+func loadTorExitList(path string) (*torExitList, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	list := parseTorExitList(data)
+	if len(list.exits) == 0 {
+		return nil, provider.NewParseError(fmt.Errorf("no exit addresses in %s", path))
+	}
+	list.updated = info.ModTime()
+	return list, nil
+}
+
+// parseTorExitList reads the exit-addresses format:
+//
+//	ExitNode <fingerprint>
+//	Published <time>
+//	LastStatus <time>
+//	ExitAddress <ip> <time>
+//
+// Lines that are a bare IP (as in torbulkexitlist) are accepted without timestamps.
+func parseTorExitList(data []byte) *torExitList {
+	list := &torExitList{exits: make(map[netip.Addr]*torExit)}
+
+	var fingerprint string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		switch fields[0] {
+		case "ExitNode":
+			fingerprint = ""
+			if len(fields) > 1 {
+				fingerprint = fields[1]
+			}
+		case "ExitAddress":
+			if len(fields) < 2 {
+				continue
+			}
+			addr, err := netip.ParseAddr(fields[1])
+			if err != nil {
+				continue
+			}
+
+			exit := list.add(addr.Unmap())
+			if fingerprint != "" && !slices.Contains(exit.fingerprints, fingerprint) {
+				exit.fingerprints = append(exit.fingerprints, fingerprint)
+			}
+			if len(fields) >= 4 {
+				seen, err := time.Parse(torTimeLayout, fields[2]+" "+fields[3])
+				if err == nil && seen.After(exit.lastSeen) {
+					exit.lastSeen = seen
+				}
+			}
+		default:
+			if addr, err := netip.ParseAddr(fields[0]); err == nil {
+				list.add(addr.Unmap())
+			}
+		}
+	}
+
+	return list
+}
+
+// add returns the entry for addr, creating it if needed.
+func (l *torExitList) add(addr netip.Addr) *torExit {
+	exit, ok := l.exits[addr]
+	if !ok {
+		exit = &torExit{}
+		l.exits[addr] = exit
+	}
+	return exit
+}
+
+func init() {
+	feeds.Register(config.Feed{Name: torExitListFeed, URL: torExitListURL})
+	provider.Register(NewTorExit())
+}
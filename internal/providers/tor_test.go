@@ -0,0 +1,115 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+const testExitAddresses = `ExitNode 0011BD2485AD45D984EC4159C88FC066E5E3300E
+Published 2024-05-01 10:01:40
+LastStatus 2024-05-01 11:00:00
+ExitAddress 192.0.2.10 2024-05-01 11:03:01
+ExitNode 0111BA9B604669E636FFD5B503F382A4B7AD6E80
+Published 2024-05-01 09:00:00
+LastStatus 2024-05-01 10:00:00
+ExitAddress 192.0.2.10 2024-05-01 09:30:00
+ExitAddress 2001:db8::10 2024-05-01 09:30:00
+`
+
+// useDataDir points the data directory at a temporary directory for the test.
+func useDataDir(t *testing.T) string {
+	t.Helper()
+
+	saved := provider.DataDir()
+	t.Cleanup(func() { provider.SetDataDir(saved) })
+	dir := t.TempDir()
+	provider.SetDataDir(dir)
+	return dir
+}
+
+func TestTorExitLookup(t *testing.T) {
+	dir := useDataDir(t)
+	if err := os.WriteFile(filepath.Join(dir, torExitListFeed), []byte(testExitAddresses), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p := NewTorExit()
+
+	tests := []struct {
+		ip               string
+		wantExit         bool
+		wantFingerprints int
+		wantLastSeen     string
+	}{
+		{ip: "192.0.2.10", wantExit: true, wantFingerprints: 2, wantLastSeen: "2024-05-01T11:03:01Z"},
+		{ip: "::ffff:192.0.2.10", wantExit: true, wantFingerprints: 2, wantLastSeen: "2024-05-01T11:03:01Z"},
+		{ip: "2001:db8::10", wantExit: true, wantFingerprints: 1, wantLastSeen: "2024-05-01T09:30:00Z"},
+		{ip: "192.0.2.11"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			result, err := p.Lookup(context.Background(), tt.ip)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp := result.Raw.(TorExitResponse)
+			if resp.IsExit != tt.wantExit || len(resp.Fingerprints) != tt.wantFingerprints || resp.LastSeen != tt.wantLastSeen {
+				t.Errorf("got %+v", resp)
+			}
+			if resp.Source != filepath.Join(dir, torExitListFeed) {
+				t.Errorf("source = %s", resp.Source)
+			}
+		})
+	}
+}
+
+func TestTorExitListNotDownloaded(t *testing.T) {
+	dir := useDataDir(t)
+	p := NewTorExit()
+
+	if p.Enabled() {
+		t.Error("enabled before the exit list was downloaded")
+	}
+	_, err := p.Lookup(context.Background(), "192.0.2.10")
+	if err == nil || !strings.Contains(err.Error(), "feeds update "+torExitListFeed) {
+		t.Errorf("err = %v, want a hint to run feeds update", err)
+	}
+	if kind := provider.KindOf(err); kind != provider.ErrorKindFeedMissing {
+		t.Errorf("kind = %q, want %q", kind, provider.ErrorKindFeedMissing)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, torExitListFeed), []byte(testExitAddresses), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !p.Enabled() {
+		t.Error("disabled after the exit list was downloaded")
+	}
+}
+
+func TestTorExitLocalPath(t *testing.T) {
+	dir := useDataDir(t)
+	if err := os.WriteFile(filepath.Join(dir, "bulk.txt"), []byte("# bulk list\n198.51.100.7\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewTorExit()
+	settings, _ := json.Marshal(TorSettings{Path: "bulk.txt"})
+	if err := p.Configure(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := p.Lookup(context.Background(), "198.51.100.7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp := result.Raw.(TorExitResponse); !resp.IsExit || !slices.Equal(resp.Fingerprints, nil) {
+		t.Errorf("got %+v", resp)
+	}
+}
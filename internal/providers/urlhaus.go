@@ -0,0 +1,145 @@
+package providers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// URLhausResponse is the URLhaus host record for the IP.
+type URLhausResponse struct {
+	Reference  string            `json:"reference"`
+	FirstSeen  string            `json:"first_seen"`
+	URLCount   int               `json:"url_count"`
+	Online     int               `json:"online"`
+	Threats    []string          `json:"threats"`
+	Tags       []string          `json:"tags"`
+	Blacklists map[string]string `json:"blacklists,omitempty"`
+	URLs       []URLhausURL      `json:"urls"`
+}
+
+// URLhausURL is a malware URL hosted on the IP.
+type URLhausURL struct {
+	URL       string   `json:"url"`
+	Status    string   `json:"status"`
+	Threat    string   `json:"threat"`
+	DateAdded string   `json:"date_added"`
+	Reference string   `json:"reference"`
+	Tags      []string `json:"tags,omitempty"`
+}
+
+// urlhausHost is the URLhaus host endpoint response.
+type urlhausHost struct {
+	QueryStatus      string            `json:"query_status"`
+	URLhausReference string            `json:"urlhaus_reference"`
+	FirstSeen        string            `json:"firstseen"`
+	URLCount         json.Number       `json:"url_count"`
+	Blacklists       map[string]string `json:"blacklists"`
+	URLs             []struct {
+		URL              string   `json:"url"`
+		URLStatus        string   `json:"url_status"`
+		Threat           string   `json:"threat"`
+		DateAdded        string   `json:"date_added"`
+		URLhausReference string   `json:"urlhaus_reference"`
+		Tags             []string `json:"tags"`
+	} `json:"urls"`
+}
+
+// URLhaus implements the Provider interface for the abuse.ch URLhaus host lookup.
+type URLhaus struct {
+	provider.BaseProvider
+}
+
+// NewURLhaus creates a new URLhaus provider. It is disabled until an abuse.ch Auth-Key is configured.
+func NewURLhaus() *URLhaus {
+	return &URLhaus{
+		BaseProvider: provider.BaseProvider{
+			ProviderName: "URLhaus",
+			ProviderID:   "urlhaus",
+			URLTemplate:  "https://urlhaus-api.abuse.ch/v1/host/",
+			BodyTemplate: "host={ip}",
+			ContentType:  provider.ContentTypeForm,
+			Auth:         provider.Auth{Scheme: provider.AuthHeader, Name: "Auth-Key"},
+		},
+	}
+}
+
+// Configure applies the provider's settings.
+func (u *URLhaus) Configure(settings json.RawMessage) error {
+	var s provider.KeySettings
+	if err := json.Unmarshal(settings, &s); err != nil {
+		return err
+	}
+	s.Apply(&u.Auth)
+	return nil
+}
+
+// ParseResponse parses the URLhaus host response.
+func (u *URLhaus) ParseResponse(body []byte, statusCode int) (*provider.Result, error) {
+	if statusCode != http.StatusOK {
+		return provider.NewErrorResult(u, statusCode, provider.NewStatusError(statusCode)), nil
+	}
+
+	var host urlhausHost
+	if err := json.Unmarshal(body, &host); err != nil {
+		return nil, provider.NewParseError(err)
+	}
+
+	switch host.QueryStatus {
+	case "ok":
+	case "no_results":
+		return provider.NewSuccessResult(u, statusCode, nil), nil
+	default:
+		return provider.NewErrorResult(u, statusCode, abuseCHError(host.QueryStatus, nil)), nil
+	}
+
+	count, _ := host.URLCount.Int64()
+	resp := URLhausResponse{
+		Reference: host.URLhausReference,
+		FirstSeen: host.FirstSeen,
+		URLCount:  int(count),
+		Threats:   []string{},
+		Tags:      []string{},
+		URLs:      make([]URLhausURL, 0, len(host.URLs)),
+	}
+
+	// Blacklists reports "not listed" for each list the host is absent from; keep only listings.
+	for list, status := range host.Blacklists {
+		if status != "not listed" {
+			if resp.Blacklists == nil {
+				resp.Blacklists = make(map[string]string)
+			}
+			resp.Blacklists[list] = status
+		}
+	}
+
+	for _, h := range host.URLs {
+		resp.URLs = append(resp.URLs, URLhausURL{
+			URL:       h.URL,
+			Status:    h.URLStatus,
+			Threat:    h.Threat,
+			DateAdded: h.DateAdded,
+			Reference: h.URLhausReference,
+			Tags:      h.Tags,
+		})
+		resp.Threats = appendUnique(resp.Threats, h.Threat)
+		resp.Tags = appendUnique(resp.Tags, h.Tags...)
+		if h.URLStatus == "online" {
+			resp.Online++
+		}
+	}
+
+	result := provider.NewSuccessResult(u, statusCode, resp)
+	switch {
+	case resp.Online > 0:
+		result.Verdict = provider.VerdictMalicious
+	case resp.URLCount > 0:
+		result.Verdict = provider.VerdictSuspicious
+	}
+	return result, nil
+}
+
+func init() {
+	provider.Register(NewURLhaus())
+}
@@ -0,0 +1,72 @@
+package providers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+func TestURLhausParseResponse(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantKind    provider.ErrorKind
+		wantError   string
+		wantVerdict provider.Verdict
+		wantOnline  int
+	}{
+		{
+			name: "online URL",
+			body: `{"query_status":"ok","url_count":"2","blacklists":{"spamhaus_dbl":"not listed","surbl":"listed"},"urls":[
+				{"url":"http://192.0.2.1/a.exe","url_status":"online","threat":"malware_download","tags":["elf"]},
+				{"url":"http://192.0.2.1/b.exe","url_status":"offline","threat":"malware_download","tags":["elf","mirai"]}]}`,
+			wantVerdict: provider.VerdictMalicious,
+			wantOnline:  1,
+		},
+		{
+			name:        "offline URLs",
+			body:        `{"query_status":"ok","url_count":"1","urls":[{"url":"http://192.0.2.1/b.exe","url_status":"offline","threat":"malware_download"}]}`,
+			wantVerdict: provider.VerdictSuspicious,
+		},
+		{name: "no results", body: `{"query_status":"no_results"}`},
+		{name: "invalid host", body: `{"query_status":"invalid_host"}`, wantKind: provider.ErrorKindAPI, wantError: "query_status=invalid_host"},
+		{name: "unknown key", body: `{"query_status":"unknown_auth_key"}`, wantKind: provider.ErrorKindAuth},
+		{name: "http error", status: http.StatusServiceUnavailable, wantKind: provider.ErrorKindHTTPStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := tt.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			result, err := NewURLhaus().ParseResponse([]byte(tt.body), status)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.ErrorKind != tt.wantKind {
+				t.Fatalf("ErrorKind = %q, want %q (%s)", result.ErrorKind, tt.wantKind, result.Error)
+			}
+			if !strings.Contains(result.Error, tt.wantError) {
+				t.Errorf("Error = %q, want it to contain %q", result.Error, tt.wantError)
+			}
+			if result.Verdict != tt.wantVerdict {
+				t.Errorf("Verdict = %q, want %q", result.Verdict, tt.wantVerdict)
+			}
+			if tt.wantVerdict == "" {
+				return
+			}
+
+			resp := result.Raw.(URLhausResponse)
+			if resp.Online != tt.wantOnline {
+				t.Errorf("Online = %d, want %d", resp.Online, tt.wantOnline)
+			}
+			if _, ok := resp.Blacklists["spamhaus_dbl"]; ok {
+				t.Errorf("Blacklists = %v, want unlisted entries dropped", resp.Blacklists)
+			}
+		})
+	}
+}
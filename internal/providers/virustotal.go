@@ -0,0 +1,322 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+const (
+	virusTotalBaseURL = "https://www.virustotal.com/api/v3/ip_addresses/"
+
+	// virusTotalDefaultLimit is how many related objects each relationship call fetches.
+	virusTotalDefaultLimit = 10
+
+	// virusTotalMaliciousEngines is how many engines must flag the IP for a malicious verdict.
+	virusTotalMaliciousEngines = 3
+)
+
+// virusTotalRelationships are the relationship calls the provider can make.
+var virusTotalRelationships = []string{"resolutions", "communicating_files"}
+
+// VirusTotalResponse is the IP report with any requested relationships.
+type VirusTotalResponse struct {
+	IP                 string                 `json:"ip"`
+	ASN                int                    `json:"asn,omitempty"`
+	ASOwner            string                 `json:"as_owner,omitempty"`
+	Country            string                 `json:"country,omitempty"`
+	Network            string                 `json:"network,omitempty"`
+	Reputation         int                    `json:"reputation"`
+	Tags               []string               `json:"tags"`
+	LastAnalysis       string                 `json:"last_analysis,omitempty"`
+	Stats              VirusTotalStats        `json:"stats"`
+	Flagged            []VirusTotalEngine     `json:"flagged"`
+	Votes              VirusTotalVotes        `json:"votes"`
+	Resolutions        []VirusTotalResolution `json:"resolutions,omitempty"`
+	CommunicatingFiles []VirusTotalFile       `json:"communicating_files,omitempty"`
+	RelationshipErrors map[string]string      `json:"relationship_errors,omitempty"`
+}
+
+// VirusTotalStats counts the engines' last analysis results by category.
+type VirusTotalStats struct {
+	Malicious  int `json:"malicious"`
+	Suspicious int `json:"suspicious"`
+	Harmless   int `json:"harmless"`
+	Undetected int `json:"undetected"`
+	Timeout    int `json:"timeout"`
+}
+
+// VirusTotalEngine is an engine that flagged the IP as malicious or suspicious.
+type VirusTotalEngine struct {
+	Engine   string `json:"engine"`
+	Category string `json:"category"`
+	Result   string `json:"result"`
+}
+
+// VirusTotalVotes are the community votes on the IP.
+type VirusTotalVotes struct {
+	Harmless  int `json:"harmless"`
+	Malicious int `json:"malicious"`
+}
+
+// VirusTotalResolution is a hostname the IP has resolved for.
+type VirusTotalResolution struct {
+	Hostname string `json:"hostname"`
+	Date     string `json:"date"`
+}
+
+// VirusTotalFile is a file seen communicating with the IP.
+type VirusTotalFile struct {
+	SHA256    string `json:"sha256"`
+	Name      string `json:"name,omitempty"`
+	Malicious int    `json:"malicious"`
+}
+
+// VirusTotalSettings are the config file settings for the VirusTotal provider.
+type VirusTotalSettings struct {
+	provider.KeySettings
+
+	// Relationships lists extra calls to make: "resolutions", "communicating_files".
+	// Each one costs a request against the API quota.
+	Relationships []string `json:"relationships"`
+
+	// RelationshipLimit caps the objects returned per relationship (default 10).
+	RelationshipLimit int `json:"relationship_limit"`
+}
+
+// virusTotalIP is the subset of the ip_address object we read.
+type virusTotalIP struct {
+	Data struct {
+		Attributes struct {
+			ASN                 int             `json:"asn"`
+			ASOwner             string          `json:"as_owner"`
+			Country             string          `json:"country"`
+			Network             string          `json:"network"`
+			Reputation          int             `json:"reputation"`
+			Tags                []string        `json:"tags"`
+			LastAnalysisDate    int64           `json:"last_analysis_date"`
+			LastAnalysisStats   VirusTotalStats `json:"last_analysis_stats"`
+			LastAnalysisResults map[string]struct {
+				Category string `json:"category"`
+				Result   string `json:"result"`
+			} `json:"last_analysis_results"`
+			TotalVotes VirusTotalVotes `json:"total_votes"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+// virusTotalRelated is a page of related objects.
+type virusTotalRelated struct {
+	Data []struct {
+		ID         string `json:"id"`
+		Attributes struct {
+			HostName          string `json:"host_name"`
+			Date              int64  `json:"date"`
+			MeaningfulName    string `json:"meaningful_name"`
+			LastAnalysisStats struct {
+				Malicious int `json:"malicious"`
+			} `json:"last_analysis_stats"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+// virusTotalError is the error body VirusTotal returns with non-2xx statuses.
+type virusTotalError struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// VirusTotal implements the LookupProvider interface for the VirusTotal v3 API.
+type VirusTotal struct {
+	provider.BaseProvider
+	relationships []string
+	limit         int
+}
+
+// NewVirusTotal creates a new VirusTotal provider. It is disabled until an API key is configured.
+func NewVirusTotal() *VirusTotal {
+	return &VirusTotal{
+		BaseProvider: provider.BaseProvider{
+			ProviderName: "VirusTotal",
+			ProviderID:   "virustotal",
+			Auth:         provider.Auth{Scheme: provider.AuthHeader, Name: "x-apikey"},
+		},
+		limit: virusTotalDefaultLimit,
+	}
+}
+
+// Configure applies the provider's settings.
+func (v *VirusTotal) Configure(settings json.RawMessage) error {
+	var s VirusTotalSettings
+	if err := json.Unmarshal(settings, &s); err != nil {
+		return err
+	}
+
+	for _, rel := range s.Relationships {
+		if !slices.Contains(virusTotalRelationships, rel) {
+			return fmt.Errorf("unknown relationship %q (supported: %v)", rel, virusTotalRelationships)
+		}
+	}
+
+	limit := s.RelationshipLimit
+	if limit == 0 {
+		limit = virusTotalDefaultLimit
+	}
+	if limit < 1 || limit > 40 {
+		return fmt.Errorf("relationship_limit must be between 1 and 40, got %d", limit)
+	}
+
+	s.KeySettings.Apply(&v.Auth)
+	v.relationships = s.Relationships
+	v.limit = limit
+	return nil
+}
+
+// Lookup fetches the IP report, then each configured relationship.
+// A failed relationship call is reported in the response rather than failing the lookup.
+func (v *VirusTotal) Lookup(ctx context.Context, ip string) (*provider.Result, error) {
+	if net.ParseIP(ip) == nil {
+		return nil, fmt.Errorf("invalid IP address: %s", ip)
+	}
+
+	var report virusTotalIP
+	statusCode, err := v.get(ctx, virusTotalBaseURL+ip, &report)
+	if provider.KindOf(err) == provider.ErrorKindNotFound {
+		return provider.NewSuccessResult(v, statusCode, nil), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := report.Data.Attributes
+	resp := VirusTotalResponse{
+		IP:         ip,
+		ASN:        attrs.ASN,
+		ASOwner:    attrs.ASOwner,
+		Country:    attrs.Country,
+		Network:    attrs.Network,
+		Reputation: attrs.Reputation,
+		Tags:       attrs.Tags,
+		Stats:      attrs.LastAnalysisStats,
+		Flagged:    []VirusTotalEngine{},
+		Votes:      attrs.TotalVotes,
+	}
+	if resp.Tags == nil {
+		resp.Tags = []string{}
+	}
+	if attrs.LastAnalysisDate > 0 {
+		resp.LastAnalysis = time.Unix(attrs.LastAnalysisDate, 0).UTC().Format(time.RFC3339)
+	}
+	for engine, r := range attrs.LastAnalysisResults {
+		if r.Category == "malicious" || r.Category == "suspicious" {
+			resp.Flagged = append(resp.Flagged, VirusTotalEngine{Engine: engine, Category: r.Category, Result: r.Result})
+		}
+	}
+	sort.Slice(resp.Flagged, func(i, j int) bool { return resp.Flagged[i].Engine < resp.Flagged[j].Engine })
+
+	for _, rel := range v.relationships {
+		var related virusTotalRelated
+		url := fmt.Sprintf("%s%s/%s?limit=%d", virusTotalBaseURL, ip, rel, v.limit)
+		if _, err := v.get(ctx, url, &related); err != nil {
+			if resp.RelationshipErrors == nil {
+				resp.RelationshipErrors = make(map[string]string)
+			}
+			resp.RelationshipErrors[rel] = err.Error()
+			continue
+		}
+
+		for _, d := range related.Data {
+			switch rel {
+			case "resolutions":
+				resp.Resolutions = append(resp.Resolutions, VirusTotalResolution{
+					Hostname: d.Attributes.HostName,
+					Date:     time.Unix(d.Attributes.Date, 0).UTC().Format(time.RFC3339),
+				})
+			case "communicating_files":
+				resp.CommunicatingFiles = append(resp.CommunicatingFiles, VirusTotalFile{
+					SHA256:    d.ID,
+					Name:      d.Attributes.MeaningfulName,
+					Malicious: d.Attributes.LastAnalysisStats.Malicious,
+				})
+			}
+		}
+	}
+
+	result := provider.NewSuccessResult(v, statusCode, resp)
+	switch {
+	case resp.Stats.Malicious >= virusTotalMaliciousEngines:
+		result.Verdict = provider.VerdictMalicious
+	case resp.Stats.Malicious > 0 || resp.Stats.Suspicious > 0:
+		result.Verdict = provider.VerdictSuspicious
+	}
+	for _, rel := range v.relationships {
+		if msg, ok := resp.RelationshipErrors[rel]; ok {
+			result.Warnings = append(result.Warnings, rel+": "+msg)
+		}
+	}
+
+	return result, nil
+}
+
+// get sends an authenticated GET and decodes the JSON body into dst.
+// VirusTotal error codes are mapped to error kinds, so an exhausted quota
+// is distinguishable from short-term throttling.
+func (v *VirusTotal) get(ctx context.Context, url string, dst any) (int, error) {
+	req, err := provider.NewRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	if err := v.Auth.Apply(req); err != nil {
+		return 0, err
+	}
+
+	resp, err := provider.Fetch(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, virusTotalStatusError(resp)
+	}
+
+	if err := json.Unmarshal(resp.Body, dst); err != nil {
+		return resp.StatusCode, provider.NewParseError(err)
+	}
+	return resp.StatusCode, nil
+}
+
+// virusTotalStatusError classifies a VirusTotal error response.
+func virusTotalStatusError(resp *provider.Response) error {
+	err := provider.NewStatusError(resp.StatusCode)
+
+	var body virusTotalError
+	if json.Unmarshal(resp.Body, &body) != nil || body.Error.Code == "" {
+		return err
+	}
+
+	switch body.Error.Code {
+	case "QuotaExceededError":
+		err.Kind = provider.ErrorKindQuota
+	case "TooManyRequestsError":
+		err.Kind = provider.ErrorKindRateLimited
+	case "WrongCredentialsError", "AuthenticationRequiredError", "ForbiddenError", "UserNotActiveError":
+		err.Kind = provider.ErrorKindAuth
+	case "NotFoundError":
+		err.Kind = provider.ErrorKindNotFound
+	}
+	err.Err = fmt.Errorf("%s: %s", body.Error.Code, body.Error.Message)
+	return err
+}
+
+func init() {
+	provider.Register(NewVirusTotal())
+}
@@ -0,0 +1,130 @@
+package providers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dalryan/ip-enrich/internal/provider"
+)
+
+// virusTotalReport returns an ip_address object with the given engine counts.
+func virusTotalReport(malicious, suspicious int) string {
+	return fmt.Sprintf(`{"data":{"attributes":{"asn":64500,"as_owner":"EXAMPLE","country":"NL",
+		"last_analysis_stats":{"malicious":%d,"suspicious":%d,"harmless":60,"undetected":20},
+		"last_analysis_results":{"EngineB":{"category":"malicious","result":"malware"},
+			"EngineA":{"category":"suspicious","result":"suspicious"},"EngineC":{"category":"harmless","result":"clean"}}}}}`,
+		malicious, suspicious)
+}
+
+func TestVirusTotalLookup(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantKind    provider.ErrorKind
+		wantError   string
+		wantVerdict provider.Verdict
+		wantRaw     bool
+	}{
+		{name: "malicious", status: 200, body: virusTotalReport(3, 0), wantVerdict: provider.VerdictMalicious, wantRaw: true},
+		{name: "one engine", status: 200, body: virusTotalReport(1, 0), wantVerdict: provider.VerdictSuspicious, wantRaw: true},
+		{name: "suspicious only", status: 200, body: virusTotalReport(0, 2), wantVerdict: provider.VerdictSuspicious, wantRaw: true},
+		{name: "clean", status: 200, body: virusTotalReport(0, 0), wantRaw: true},
+		{name: "not found", status: 404, body: `{"error":{"code":"NotFoundError","message":"Resource not found."}}`},
+		{
+			name:      "quota exceeded",
+			status:    429,
+			body:      `{"error":{"code":"QuotaExceededError","message":"Quota exceeded"}}`,
+			wantKind:  provider.ErrorKindQuota,
+			wantError: "QuotaExceededError: Quota exceeded",
+		},
+		{
+			name:      "throttled",
+			status:    429,
+			body:      `{"error":{"code":"TooManyRequestsError","message":"Too many requests"}}`,
+			wantKind:  provider.ErrorKindRateLimited,
+			wantError: "TooManyRequestsError: Too many requests",
+		},
+		{
+			name:      "wrong key",
+			status:    401,
+			body:      `{"error":{"code":"WrongCredentialsError","message":"Wrong API key"}}`,
+			wantKind:  provider.ErrorKindAuth,
+			wantError: "WrongCredentialsError: Wrong API key",
+		},
+		{name: "error without body", status: 502, wantKind: provider.ErrorKindHTTPStatus},
+		{name: "malformed", status: 200, body: `{"data":`, wantKind: provider.ErrorKindParse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vt := NewVirusTotal()
+			if err := vt.Configure([]byte(`{"api_key":"test"}`)); err != nil {
+				t.Fatal(err)
+			}
+
+			result := runAgainst(t, vt, "192.0.2.1", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("x-apikey") != "test" {
+					t.Errorf("x-apikey = %q, want the configured key", r.Header.Get("x-apikey"))
+				}
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+
+			if result.ErrorKind != tt.wantKind {
+				t.Fatalf("ErrorKind = %q, want %q (%s)", result.ErrorKind, tt.wantKind, result.Error)
+			}
+			if !strings.Contains(result.Error, tt.wantError) {
+				t.Errorf("Error = %q, want it to contain %q", result.Error, tt.wantError)
+			}
+			if result.Verdict != tt.wantVerdict {
+				t.Errorf("Verdict = %q, want %q", result.Verdict, tt.wantVerdict)
+			}
+			if (result.Raw != nil) != tt.wantRaw {
+				t.Errorf("Raw = %+v, want data %v", result.Raw, tt.wantRaw)
+			}
+			if tt.wantRaw {
+				resp := result.Raw.(VirusTotalResponse)
+				if resp.ASN != 64500 || len(resp.Flagged) != 2 || resp.Flagged[0].Engine != "EngineA" {
+					t.Errorf("unexpected report: %+v", resp)
+				}
+			}
+		})
+	}
+}
+
+func TestVirusTotalLookupRelationshipFailure(t *testing.T) {
+	vt := NewVirusTotal()
+	if err := vt.Configure([]byte(`{"api_key":"test","relationships":["resolutions","communicating_files"],"relationship_limit":5}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	result := runAgainst(t, vt, "192.0.2.1", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/ip_addresses/192.0.2.1":
+			fmt.Fprint(w, virusTotalReport(0, 0))
+		case "/api/v3/ip_addresses/192.0.2.1/resolutions":
+			if r.URL.Query().Get("limit") != "5" {
+				t.Errorf("limit = %q, want 5", r.URL.Query().Get("limit"))
+			}
+			fmt.Fprint(w, `{"data":[{"id":"192.0.2.1example.com","attributes":{"host_name":"example.com","date":1700000000}}]}`)
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"error":{"code":"ForbiddenError","message":"premium only"}}`)
+		}
+	}))
+
+	if result.Error != "" {
+		t.Fatalf("lookup failed: %s", result.Error)
+	}
+	resp := result.Raw.(VirusTotalResponse)
+	if len(resp.Resolutions) != 1 || resp.Resolutions[0].Hostname != "example.com" {
+		t.Errorf("Resolutions = %+v", resp.Resolutions)
+	}
+	want := "communicating_files: ForbiddenError: premium only"
+	if len(result.Warnings) != 1 || !strings.HasPrefix(result.Warnings[0], want) {
+		t.Errorf("Warnings = %q, want [%q]", result.Warnings, want)
+	}
+}
@@ -9,6 +9,6 @@ import (
 // main is the entrypoint for the tool
 func main() {
 	if err := cmd.Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(cmd.ExitCode(err))
 	}
 }